@@ -3,19 +3,16 @@ package config
 import (
 	// lib
 	. "github.com/Kenshin/cprint"
-	"github.com/tsuru/config"
 
 	// go
-	"bufio"
+	"errors"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
-	"regexp"
+	"os/exec"
 	"runtime"
-	"strconv"
 	"strings"
-	"time"
 
 	// local
 	"gnvm/util"
@@ -23,9 +20,13 @@ import (
 
 var configPath, globalversion, latsetversion string
 
+// .gnvmrc content
+var rc = new(Gnvmrc)
+
 const (
 	VERSION = "0.2.0"
 	CONFIG  = ".gnvmrc"
+	BACKUP  = ".bak"
 	NEWLINE = "\n"
 
 	REGISTRY     = "registry"
@@ -42,6 +43,13 @@ const (
 	LATEST_VERSION_KEY = LATEST_VERSION + ": "
 	LATEST_VERSION_VAL = util.UNKNOWN
 
+	IOJS_REGISTRY    = "iojsregistry"
+	NPM_REGISTRY     = "npmregistry"
+	NPM_METADATA     = "npmmetadata"
+	UPDATE_URL       = "updateurl"
+	URL_TEMPLATE     = "urltemplate"
+	DEFAULT_PACKAGES = "defaultpackages"
+
 	//CURRENT_VERSION     = "currentversion"
 	//CURRENT_VERSION_KEY = "currentversion: "
 	//CURRENT_VERSION_VAL = UNKNOWN
@@ -58,7 +66,7 @@ func init() {
 	}()
 
 	// set config path
-	configPath = util.GlobalNodePath + util.DIVIDE + CONFIG
+	configPath = util.HomePath + util.DIVIDE + CONFIG
 
 	// config file is exist
 	file, err := os.Open(configPath)
@@ -71,6 +79,17 @@ func init() {
 	// read config
 	readConfig()
 
+	// load system, user, project and env layers
+	loadLayers()
+
+	// validate effective config
+	if issues := effective().Validate(); len(issues) > 0 {
+		for _, issue := range issues {
+			P(WARING, "config %v is %v, %v\n", issue.Key, issue.Value, issue.Message)
+		}
+		P(WARING, "config file %v has %v issues. See '%v'.\n", configPath, len(issues), "gnvm config validate")
+	}
+
 }
 
 /*
@@ -78,33 +97,18 @@ func init() {
 */
 func createConfig() {
 
-	// create file
-	file, err := os.Create(configPath)
-	defer file.Close()
-	if err != nil {
-		P(ERROR, "config file create Error: %v\n", err.Error())
-		return
-	}
-
 	// get <root>/node.exe version
-	version, err := util.GetNodeVer(util.GlobalNodePath)
-	if err != nil {
+	if globalversion = detectGlobalVersion(); globalversion == GLOBAL_VERSION_VAL {
 		P(WARING, "not found %v node.exe, please use '%v'. See '%v'.\n", "global", "gnvm install x.xx.xx -g", "gnvm help install")
-		globalversion = GLOBAL_VERSION_VAL
-	} else {
-		globalversion = version
-		// add suffix
-		if runtime.GOARCH == "amd64" {
-			if bit, err := util.Arch(util.GlobalNodePath); err == nil && bit == "x86" {
-				globalversion += "-" + bit
-			}
-		}
 	}
 
-	//write init config
-	_, fileErr := file.WriteString(REGISTRY_KEY + util.ORIGIN_DEFAULT + NEWLINE + NODEROOT_KEY + util.GlobalNodePath + NEWLINE + GLOBAL_VERSION_KEY + globalversion + NEWLINE + LATEST_VERSION_KEY + LATEST_VERSION_VAL)
-	if fileErr != nil {
-		P(ERROR, "write config file Error: %v\n", fileErr.Error())
+	// write init config, default values are not written, they are from default layer, so system layer can override them
+	rc = &Gnvmrc{SchemaVersion: SCHEMA_VERSION_VAL}
+	if globalversion != GLOBAL_VERSION_VAL {
+		rc.GlobalVersion = globalversion
+	}
+	if err := writeConfig(); err != nil {
+		P(ERROR, "write config file Error: %v\n", err.Error())
 		return
 	}
 
@@ -112,53 +116,187 @@ func createConfig() {
 }
 
 /*
- Read .gnvmrc file
+ Read .gnvmrc file, auto migrate old format
 */
 func readConfig() {
-	if err := config.ReadConfigFile(configPath); err != nil {
+	data, err := ioutil.ReadFile(configPath)
+	if err == nil {
+		rc, err = parseGnvmrc(data)
+	}
+	if err != nil {
+		rc = new(Gnvmrc)
 		P(ERROR, "read config file fail, please use '%v'. \nError: %v\n", "gnvm config INIT", err.Error())
 		return
 	}
+
+	if old := rc.SchemaVersion; rc.migrate() {
+		if err := writeConfig(); err != nil {
+			P(ERROR, "migrate config file Error: %v\n", err.Error())
+		} else {
+			P(NOTICE, "config file %v migrate schema version %v to %v.\n", configPath, old, SCHEMA_VERSION_VAL)
+		}
+	}
+
+}
+
+/*
+ Crash-safe write .gnvmrc file, when old .gnvmrc is valid format, keep it to .gnvmrc.bak
+
+ Return:
+ 	- error
+*/
+func writeConfig() error {
+	data, err := rc.Bytes()
+	if err != nil {
+		return err
+	}
+
+	// backup previous good config
+	if old, err := ioutil.ReadFile(configPath); err == nil {
+		if _, err := parseGnvmrc(old); err == nil {
+			if err := util.WriteFile(configPath+BACKUP, old, 0777); err != nil {
+				P(WARING, "backup config file %v Error: %v\n", configPath+BACKUP, err.Error())
+			}
+		}
+	}
+
+	// write new config
+	return util.WriteFile(configPath, data, 0777)
+}
+
+/*
+ Restore .gnvmrc file from .gnvmrc.bak, usage 'gnvm config restore'
+
+ Return:
+ 	- error
+*/
+func Restore() error {
+	data, err := ioutil.ReadFile(configPath + BACKUP)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("backup file " + configPath + BACKUP + " not exist.")
+		}
+		return err
+	}
+	bak, err := parseGnvmrc(data)
+	if err != nil {
+		return errors.New("backup file " + configPath + BACKUP + " format error, " + err.Error())
+	}
+	bak.migrate()
+
+	// install layer keep rc pointer, so copy value
+	*rc = *bak
+	if err := writeConfig(); err != nil {
+		return err
+	}
+	P(DEFAULT, "config file %v restore success from %v.\n", configPath, configPath+BACKUP)
+	return nil
 }
 
 /*
- Write config property value from .gnvmrc file
+ Write config property value from .gnvmrc file, value parse by key registry
 
  Param:
  	- key:   config property, include: registry noderoot latestversion globalversion
  	- value: config property value
 
+ Return:
+ 	- new value, empty is fail
 */
 func SetConfig(key string, value interface{}) string {
-	if key == "registry" {
-		if !strings.HasPrefix(value.(string), "http://") {
-			P(WARING, "%v need %v", value.(string), "http://", "\n")
-			value = "http://" + value.(string)
-		}
-		if !strings.HasSuffix(value.(string), "/") {
-			value = value.(string) + "/"
+	k := LookupKey(key)
+	if k == nil {
+		P(ERROR, "%v not a valid config keyword. See '%v'.\n", key, "gnvm help config")
+		return ""
+	}
+
+	newValue, err := k.Parse(value.(string))
+	if err != nil {
+		P(ERROR, "%v value %v %v\n", k.Name, util.Redact(newValue), err.Error())
+		return ""
+	}
+	if k.Name == REGISTRY || k.Name == IOJS_REGISTRY || k.Name == NPM_REGISTRY {
+		if err := util.PolicyRegistry(newValue); err != nil {
+			P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm policy")
+			return ""
 		}
-		reg, _ := regexp.Compile(`^https?:\/\/(w{3}\.)?([-a-zA-Z0-9.])+(\.[a-zA-Z]+)(:\d{1,4})?(\/)+`)
-		if !reg.MatchString(value.(string)) {
-			P(ERROR, "%v value %v must valid url.\n", "registry", value.(string))
+	}
+	if k.Type == TYPE_URL || k.Type == TYPE_FILEURL {
+		if newValue, err = stripCredential(newValue); err != nil {
+			P(ERROR, "save credential of %v Error: %v\n", k.Name, err.Error())
 			return ""
 		}
 	}
 
-	// set new value
-	config.Set(key, value)
+	if !writeField(k.Name, newValue) {
+		return ""
+	}
+	return newValue
+}
+
+/*
+ Remove config property from .gnvmrc file, effective value fall back to lower layer, usage 'gnvm config unset <key>'
+
+ Param:
+ 	- key: config property
+
+ Return:
+ 	- true( success ) false( fail )
+*/
+func UnsetConfig(key string) bool {
+	k := LookupKey(key)
+	if k == nil {
+		P(ERROR, "%v not a valid config keyword. See '%v'.\n", key, "gnvm help config")
+		return false
+	}
+	return writeField(k.Name, "")
+}
+
+/*
+ Reset config property to default value, usage 'gnvm config reset <key>'
 
-	// delete old config
-	if err := os.Remove(configPath); err != nil {
-		P(ERROR, "remove config file Error: %v\n", err.Error())
+ Param:
+ 	- key: config property
+
+ Return:
+ 	- new value, empty is fail
+*/
+func ResetKey(key string) string {
+	k := LookupKey(key)
+	if k == nil {
+		P(ERROR, "%v not a valid config keyword. See '%v'.\n", key, "gnvm help config")
+		return ""
+	}
+	// optional key and default value reset is remove, value from system layer, default layer or registry profile
+	value := k.ResetValue()
+	if k.Optional || value == k.Default {
+		if !writeField(k.Name, "") {
+			return ""
+		}
+		return GetConfig(k.Name)
+	}
+	if !writeField(k.Name, value) {
+		return ""
 	}
+	return value
+}
+
+/*
+ Set install layer field and write .gnvmrc file
+*/
+func writeField(key, value string) bool {
+	*rc.field(key) = value
 
 	// write new config
-	if err := config.WriteConfigFile(configPath, 0777); err != nil {
+	if err := writeConfig(); err != nil {
 		P(ERROR, "write config file Error: %v\n", err.Error())
+		return false
 	}
 
-	return value.(string)
+	if layer := overridden(key); layer != nil {
+		P(WARING, "%v is overridden by %v layer %v, current effective value is %v.\n", key, layer.Name, util.Redact(layer.Source(key)), util.Redact(GetConfig(key)))
+	}
+	return true
 }
 
 /*
@@ -172,156 +310,231 @@ func SetConfig(key string, value interface{}) string {
 
 */
 func GetConfig(key string) string {
-	value, err := config.GetString(key)
-	if err != nil {
-		value = util.UNKNOWN
+	if layer := Origin(key); layer != nil {
+		k := LookupKey(key)
+		if layer.Name == LAYER_DEFAULT && k.Derive != nil {
+			if value := k.Derive(); value != "" {
+				return value
+			}
+		}
+		// relative path is relative to home in portable mode
+		if k.Type == TYPE_PATH {
+			return util.ResolvePath(*layer.rc.field(key))
+		}
+		return *layer.rc.field(key)
 	}
-	return value
+	return util.UNKNOWN
 }
 
 /*
- Init config property value from .gnvmrc file
+ Validate .gnvmrc file and print issues, usage 'gnvm config validate'
+
+ Return:
+ 	- true( valid ) false( has issues )
 */
-func ReSetConfig() {
-	if newValue := SetConfig(REGISTRY, util.ORIGIN_DEFAULT); newValue != "" {
-		P(NOTICE, "%v      init success, new value is %v\n", REGISTRY, newValue)
+func Validate() bool {
+	issues := effective().Validate()
+	if util.IsMachine() {
+		if err := util.Render(issues); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm config validate", err.Error())
+		}
+		return len(issues) == 0
 	}
-	if newValue := SetConfig(NODEROOT, util.GlobalNodePath); newValue != "" {
-		P(NOTICE, "%v      init success, new value is %v\n", NODEROOT, newValue)
+	P(NOTICE, "validate config file %v, schema version is %v.\n", configPath, rc.SchemaVersion)
+	for _, issue := range issues {
+		P(ERROR, "%v %v %v\n", issue.Key, CP{Red, false, None, false, util.Redact(issue.Value)}, issue.Message)
 	}
-	version, err := util.GetNodeVer(util.GlobalNodePath)
-	if err != nil {
-		P(WARING, "not found %v node.exe, please use '%v'. See '%v'.\n", "global", "gnvm install x.xx.xx -g", "gnvm help install")
-		globalversion = GLOBAL_VERSION_VAL
+	if len(issues) == 0 {
+		P(DEFAULT, "config file %v is %v.\n", configPath, "valid")
 	} else {
-		globalversion = version
-		// add suffix
-		if runtime.GOARCH == "amd64" {
-			if bit, err := util.Arch(util.GlobalNodePath); err == nil && bit == "x86" {
-				globalversion += "-" + bit
-			}
-		}
-	}
-	if newValue := SetConfig(GLOBAL_VERSION, globalversion); newValue != "" {
-		P(NOTICE, "%v init success, new value is %v\n", GLOBAL_VERSION, newValue)
+		P(WARING, "found %v issues, please use '%v' or '%v'. See '%v'.\n", len(issues), "gnvm config registry xxx", "gnvm config INIT", "gnvm help config")
 	}
+	return len(issues) == 0
 }
 
 /*
- Print all config property value from .gnvmrc file
+ Open .gnvmrc file with $EDITOR, validate on save, usage 'gnvm config edit'
+
+ Return:
+ 	- error
 */
-func List() {
-	P(NOTICE, "config file path %v \n", configPath)
-	f, err := os.Open(configPath)
+func Edit() error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		if runtime.GOOS == "windows" {
+			editor = []string{"notepad"}
+		} else {
+			editor = []string{"vi"}
+		}
+	}
+
+	data, err := rc.Bytes()
 	if err != nil {
-		P(ERROR, "read config file fail, please use '%v'. \nError: %v\n", "gnvm config INIT", err.Error())
-		return
+		return err
 	}
-	buf := bufio.NewReader(f)
+	tmp := configPath + ".edit"
+	if err := ioutil.WriteFile(tmp, data, 0777); err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
 	for {
-		line, _, err := buf.ReadLine()
-		if err == io.EOF {
-			break
+		cmd := exec.Command(editor[0], append(editor[1:], tmp)...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return errors.New("run editor " + strings.Join(editor, " ") + " fail, " + err.Error())
+		}
+
+		var issues Issues
+		data, err := ioutil.ReadFile(tmp)
+		if err != nil {
+			return err
+		}
+		edited, err := parseGnvmrc(data)
+		if err != nil {
+			issues = Issues{{CONFIG, "", "format error, " + err.Error()}}
+		} else {
+			edited.migrate()
+			check := *edited
+			check.fill()
+			issues = check.Validate()
+		}
+
+		if len(issues) == 0 {
+			*rc = *edited
+			if err := writeConfig(); err != nil {
+				return err
+			}
+			P(DEFAULT, "config file %v edit success.\n", configPath)
+			return nil
+		}
+
+		for _, issue := range issues {
+			P(ERROR, "%v %v %v\n", issue.Key, CP{Red, false, None, false, util.Redact(issue.Value)}, issue.Message)
 		}
-		arr := strings.SplitN(string(line), ":", 2)
-		if len(arr) == 2 {
-			P(DEFAULT, "gnvm config %v is %v\n", strings.TrimSpace(arr[0]), strings.TrimSpace(arr[1]))
+		prompt := "n"
+		P(NOTICE, "found %v issues, re-edit config file [Y/n]? ", len(issues))
+		fmt.Scanf("%s\n", &prompt)
+		if strings.ToLower(prompt) != "y" {
+			return errors.New("config file " + configPath + " not changed.")
 		}
 	}
 }
 
 /*
- Get io.js url
+ Init config property value from .gnvmrc file
+*/
+func ReSetConfig() {
+	for _, key := range []string{REGISTRY, NODEROOT, GLOBAL_VERSION} {
+		if newValue := ResetKey(key); newValue != "" {
+			P(NOTICE, "%v init success, new value is %v\n", key, newValue)
+		}
+	}
+	if GetConfig(GLOBAL_VERSION) == GLOBAL_VERSION_VAL {
+		P(WARING, "not found %v node.exe, please use '%v'. See '%v'.\n", "global", "gnvm install x.xx.xx -g", "gnvm help install")
+	}
+}
 
- Param:
- 	- url:   config property, include: registry noderoot latestversion globalversion
+/*
+ Structured result of gnvm config, usage --output json|yaml|tsv and --format
 
- Return:
- 	- value: config property value
+ - path:       install .gnvmrc path
+ - properties: effective config property collection
+*/
+type ConfigList struct {
+	Path       string        `json:"path" yaml:"path"`
+	Properties []ConfigEntry `json:"properties" yaml:"properties"`
+}
 
+/*
+ - key:    config property, e.g. registry
+ - value:  config property effective value
+ - layer:  layer name of value, include: default system install user project env flag
+ - origin: source of value, e.g. file path, env GNVM_REGISTRY, only --show-origin
 */
-func GetIOURL(url string) string {
-	if url == util.ORIGIN_TAOBAO {
-		url = strings.Replace(url, "/node", "/iojs", -1)
-	} else if url == util.ORIGIN_DEFAULT {
-		url = strings.Replace(url, "nodejs.org", "iojs.org", -1)
+type ConfigEntry struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+	Layer  string `json:"layer,omitempty" yaml:"layer,omitempty"`
+	Origin string `json:"origin,omitempty" yaml:"origin,omitempty"`
+}
+
+func (this *ConfigList) Header() []string {
+	return []string{"key", "value", "layer", "origin"}
+}
+
+func (this *ConfigList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Properties {
+		rows = append(rows, []string{v.Key, v.Value, v.Layer, v.Origin})
 	}
-	return url
+	return rows
 }
 
 /*
- Verify config registry url structural correctness, include:
- 	- url:  <url>
- 	- json: <url>/index.json
+ Return config entry of key
+
+ Param:
+ 	- key:        config property
+ 	- showOrigin: true( include layer and origin )
 */
-func Verify() {
-	code := make(chan int)
-	fail := make(chan interface{})
-	finish := false
-	registry := GetConfig(REGISTRY)
-	wait := func() {
-		wait := ""
-		for {
-			time.Sleep(time.Millisecond * 1000)
-			if finish {
-				break
+func Entry(key string, showOrigin bool) ConfigEntry {
+	entry := ConfigEntry{Key: key, Value: util.Redact(GetConfig(key))}
+	if layer := Origin(key); showOrigin && layer != nil {
+		entry.Layer, entry.Origin = layer.Name, util.Redact(layer.Source(key))
+		if layer.Name == LAYER_DEFAULT && entry.Value != layer.rc.fieldValue(key) {
+			if profile := ActiveProfile(); profile != nil {
+				entry.Origin = "registry profile " + profile.Name
+			} else {
+				entry.Origin = "registry " + util.Redact(GetConfig(REGISTRY))
 			}
-			wait += "."
-			fmt.Printf(wait)
 		}
 	}
+	return entry
+}
 
-	go verifyURL("url", registry, code, fail)
-	go wait()
+/*
+ Print all effective config property value
 
-	for {
-		select {
-		case <-time.After(time.Second * 10):
-			cp1 := CP{Red, false, None, false, "fail"}
-			cp2 := CP{Red, false, None, false, "time out"}
-			P(DEFAULT, "%v. \n", cp1)
-			P(ERROR, "gnvm config registry %v vaild %v, Error: %v.", registry, cp1, cp2)
-			return
-		case value, ok := <-code:
-			if ok && value == 200 {
-				finish = true
-				P(DEFAULT, "%v.\n", CP{Magenta, false, None, false, " ok"})
-				go verifyURL("json", registry+util.NODELIST, code, fail)
-				finish = false
-				go wait()
-			} else if !ok {
-				P(DEFAULT, "%v.\n", CP{Magenta, false, None, false, " ok"})
-				return
-			}
-		case value, _ := <-fail:
-			cp := CP{Red, false, None, false, " fail"}
-			if v, ok := value.(int); ok {
-				P(DEFAULT, "%v, response code: %v.\n", cp, strconv.Itoa(v))
-			} else {
-				P(DEFAULT, "%v.\n", cp)
-				Error(ERROR, "", value)
-			}
-			close(fail)
-			finish = true
-			return
+ Param:
+ 	- showOrigin: true( print layer and origin of value )
+*/
+func List(showOrigin bool) {
+	P(NOTICE, "config file path %v \n", configPath)
+	list := &ConfigList{Path: configPath, Properties: []ConfigEntry{}}
+	for _, key := range Keys() {
+		entry := Entry(key, showOrigin)
+		list.Properties = append(list.Properties, entry)
+		if util.IsMachine() {
+			continue
+		}
+		if showOrigin {
+			P(DEFAULT, "gnvm config %v is %v ( %v %v )\n", entry.Key, entry.Value, entry.Layer, entry.Origin)
+		} else {
+			P(DEFAULT, "gnvm config %v is %v\n", entry.Key, entry.Value)
+		}
+	}
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm config", err.Error())
 		}
 	}
 }
 
-func verifyURL(status string, url string, code chan int, fail chan interface{}) {
-	P(NOTICE, "gnvm config registry %v valid ", url)
-	time.Sleep(time.Second * 2)
-	if resp, err := http.Get(url); err == nil {
-		if resp.StatusCode == 200 {
-			if status == "url" {
-				code <- resp.StatusCode
-			} else {
-				close(code)
-			}
-		} else {
-			fail <- resp.StatusCode
+/*
+ Verify config registry url structural correctness, include:
+ 	- url:  <url>
+ 	- json: <url>/index.json
+*/
+func Verify() {
+	registry := GetConfig(REGISTRY)
+	for _, url := range []string{registry, registry + util.NODELIST} {
+		P(NOTICE, "gnvm config registry %v valid ", util.Redact(url))
+		result := util.ProbeTimeout(http.MethodGet, url, util.PROBE_TIMEOUT)
+		if result.Err != nil {
+			P(DEFAULT, "%v, Error: %v.\n", CP{Red, false, None, false, "fail"}, util.Redact(result.Err.Error()))
+			return
 		}
-	} else {
-		fail <- err
+		P(DEFAULT, "%v, %v.\n", CP{Magenta, false, None, false, "ok"}, result.Latency)
 	}
 }
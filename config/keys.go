@@ -0,0 +1,241 @@
+package config
+
+import (
+	// go
+	"errors"
+	"runtime"
+	"strings"
+
+	// local
+	"gnvm/util"
+)
+
+const (
+	TYPE_STRING  = "string"
+	TYPE_URL     = "url"
+	TYPE_FILEURL = "fileurl"
+	TYPE_PATH    = "path"
+	TYPE_VERSION = "version"
+)
+
+/*
+ Config key declaration
+
+ - Name:     config property, e.g. registry
+ - Type:     value type, include: string url( folder url, end with / ) fileurl path version
+ - Default:  default value, usage default layer and 'gnvm config unset'
+ - Usage:    key description
+ - Aliases:  return alias -> value, ignore case, e.g. registry DEFAULT -> http://nodejs.org/dist/
+ - Validate: extra validator after type check, nil is not need
+ - Detect:   detect value from environment when 'gnvm config reset', nil is Default
+ - Derive:   derive default value from other keys, e.g. iojsregistry from registry profile, empty is Default
+ - Optional: reset is remove from .gnvmrc, value from default layer or registry profile
+*/
+type Key struct {
+	Name     string
+	Type     string
+	Default  string
+	Usage    string
+	Aliases  func() map[string]string
+	Validate func(value string) error
+	Detect   func() string
+	Derive   func() string
+	Optional bool
+}
+
+// key registry, sort by .gnvmrc
+var keys = []*Key{
+	{
+		Name:    REGISTRY,
+		Type:    TYPE_URL,
+		Default: util.ORIGIN_DEFAULT,
+		Usage:   "Node.js download url, e.g. " + util.ORIGIN_DEFAULT,
+	},
+	{
+		Name:    NODEROOT,
+		Type:    TYPE_PATH,
+		Default: util.PortablePath(util.GlobalNodePath),
+		Usage:   "global node.exe folder, default is <GNVM_HOME>\\global when GNVM_HOME is set, relative to home in portable mode, usage 'gnvm reg noderoot'",
+	},
+	{
+		Name:    GLOBAL_VERSION,
+		Type:    TYPE_VERSION,
+		Default: GLOBAL_VERSION_VAL,
+		Usage:   "global Node.js version, e.g. 5.10.1 5.10.1-x86",
+		Detect:  detectGlobalVersion,
+	},
+	{
+		Name:    LATEST_VERSION,
+		Type:    TYPE_VERSION,
+		Default: LATEST_VERSION_VAL,
+		Usage:   "latest Node.js version, e.g. 5.10.1",
+		Validate: func(value string) error {
+			if strings.Contains(value, "-") {
+				return errors.New("must be " + util.UNKNOWN + " or valid Node.js version, e.g. 5.10.1")
+			}
+			return nil
+		},
+	},
+	{
+		Name:     IOJS_REGISTRY,
+		Type:     TYPE_URL,
+		Default:  util.IOJS_DEFAULT,
+		Usage:    "io.js download url, default follow registry profile, or same as registry when profile not provide, e.g. " + util.IOJS_DEFAULT,
+		Optional: true,
+	},
+	{
+		Name:     NPM_REGISTRY,
+		Type:     TYPE_URL,
+		Default:  util.NPM_DEFAULT,
+		Usage:    "npm zip download url, default follow registry profile, e.g. " + util.NPM_TAOBAO,
+		Optional: true,
+	},
+	{
+		Name:     NPM_METADATA,
+		Type:     TYPE_FILEURL,
+		Default:  util.NPM_METADATA,
+		Usage:    "npm latest package.json url, usage 'gnvm npm latest'",
+		Optional: true,
+	},
+	{
+		Name:     UPDATE_URL,
+		Type:     TYPE_URL,
+		Default:  util.UPDATE_URL,
+		Usage:    "gnvm self-update url, include CHANGELOG.md, usage 'gnvm version -r'",
+		Optional: true,
+	},
+	{
+		Name:     URL_TEMPLATE,
+		Type:     TYPE_STRING,
+		Usage:    "download url template of registry, default follow registry profile, empty is nodejs.org layout, e.g. {base}/{version}/{flavor}-{version}-{os}-{arch}.{ext}",
+		Validate: util.VerifyTemplate,
+		Optional: true,
+	},
+	{
+		Name:     DEFAULT_PACKAGES,
+		Type:     TYPE_STRING,
+		Usage:    "global npm packages installed to each new version, space or comma separated, with <home>\\" + util.DEFAULT_PACKAGES + " file, e.g. typescript pnpm@8",
+		Validate: util.VerifyPackages,
+		Optional: true,
+	},
+}
+
+/*
+ Return all schema keys, sort by .gnvmrc
+*/
+func Keys() []string {
+	var names []string
+	for _, key := range keys {
+		names = append(names, key.Name)
+	}
+	return names
+}
+
+/*
+ Return all key declarations, sort by .gnvmrc
+*/
+func KeyList() []*Key {
+	return keys
+}
+
+/*
+ Find key declaration, ignore case
+
+ Param:
+ 	- name: config property, e.g. Registry
+
+ Return:
+ 	- *Key: nil is not found
+*/
+func LookupKey(name string) *Key {
+	for _, key := range keys {
+		if strings.EqualFold(key.Name, name) {
+			return key
+		}
+	}
+	return nil
+}
+
+/*
+ Parse value by key type, resolve aliases, normalize and validate
+
+ Param:
+ 	- value: config property value, e.g. npm.taobao.org/mirrors/node TAOBAO corp D:\nodedist
+
+ Return:
+ 	- string: normalized value, e.g. http://npm.taobao.org/mirrors/node/
+ 	- error
+*/
+func (this *Key) Parse(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if this.Aliases != nil {
+		for alias, v := range this.Aliases() {
+			if strings.EqualFold(alias, value) {
+				value = v
+			}
+		}
+	}
+
+	switch this.Type {
+	case TYPE_URL, TYPE_FILEURL:
+		if util.IsLocalPath(value) {
+			value = util.FileURL(value)
+		} else if !strings.Contains(value, "://") {
+			value = "http://" + value
+		}
+		if this.Type == TYPE_URL && !strings.HasSuffix(value, "/") {
+			value += "/"
+		}
+		if !isValidURL(value) {
+			return value, errors.New("must be valid url or local path, e.g. " + this.Default)
+		}
+		if path := util.LocalPath(value); path != "" && !util.IsDirExist(path) {
+			return value, errors.New(path + " not exist.")
+		}
+	case TYPE_PATH:
+		if value == "" {
+			return value, errors.New("can't be empty.")
+		}
+		if !util.IsDirExist(util.ResolvePath(value)) {
+			return value, errors.New("folder not exist.")
+		}
+	case TYPE_VERSION:
+		if !isValidVersion(value) {
+			return value, errors.New("must be " + util.UNKNOWN + " or valid Node.js version, e.g. 5.10.1 5.10.1-x86")
+		}
+	}
+
+	if this.Validate != nil {
+		if err := this.Validate(value); err != nil {
+			return value, err
+		}
+	}
+	return value, nil
+}
+
+/*
+ Return reset value, detect from environment or default value
+*/
+func (this *Key) ResetValue() string {
+	if this.Detect != nil {
+		return this.Detect()
+	}
+	return this.Default
+}
+
+/*
+ Detect global version from <root>/node.exe, e.g. 5.10.1 5.10.1-x86 unknown
+*/
+func detectGlobalVersion() string {
+	version, err := util.GetNodeVer(util.GlobalNodePath)
+	if err != nil {
+		return GLOBAL_VERSION_VAL
+	}
+	// add suffix
+	if runtime.GOARCH == "amd64" {
+		if bit, err := util.Arch(util.GlobalNodePath); err == nil && bit == "x86" {
+			version += "-" + bit
+		}
+	}
+	return version
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	// local
+	"gnvm/util"
+)
+
+const (
+	LAYER_DEFAULT = "default"
+	LAYER_SYSTEM  = "system"
+	LAYER_INSTALL = "install"
+	LAYER_USER    = "user"
+	LAYER_PROJECT = "project"
+	LAYER_ENV     = "env"
+	LAYER_FLAG    = "flag"
+
+	ENV_PREFIX = "GNVM_"
+)
+
+/*
+ Config layer, precedence from low to high:
+ 	- default: built-in value
+ 	- system:  machine-wide file, %ProgramData%\gnvm\.gnvmrc
+ 	- install: <root>/.gnvmrc, gnvm config set write it
+ 	- user:    per-user file, %USERPROFILE%\.gnvmrc, skip in portable mode
+ 	- project: per-project .gnvmrc, found by walking up from the working directory
+ 	- env:     GNVM_<KEY> environment variables, e.g. GNVM_REGISTRY
+ 	- flag:    gnvm -c <key>=<value>
+
+ - Name: layer name
+ - Path: file path, empty is not file layer
+*/
+type Layer struct {
+	Name string
+	Path string
+	rc   *Gnvmrc
+}
+
+// config layers, sort by precedence from low to high
+var layers []*Layer
+
+/*
+ Return source of key in this layer, e.g.
+ 	- C:\Users\xxx\.gnvmrc
+ 	- GNVM_REGISTRY
+ 	- -c registry=xxx
+*/
+func (this *Layer) Source(key string) string {
+	switch this.Name {
+	case LAYER_ENV:
+		return envName(key)
+	case LAYER_FLAG:
+		return "-c " + key + "=" + *this.rc.field(key)
+	case LAYER_DEFAULT:
+		return "built-in"
+	}
+	return this.Path
+}
+
+/*
+ Load all config layers, install layer is rc
+*/
+func loadLayers() {
+	def := new(Gnvmrc)
+	def.fill()
+
+	// built-in registry profiles
+	def.Registries = map[string]string{}
+	for name, url := range defaultRegistries {
+		def.Registries[name] = url
+	}
+	layers = []*Layer{{LAYER_DEFAULT, "", def}}
+
+	exclude := map[string]bool{configPath: true}
+	addFile := func(name, path string) {
+		if path == "" || exclude[path] {
+			return
+		}
+		exclude[path] = true
+		if !util.IsDirExist(path) {
+			return
+		}
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			var layer *Gnvmrc
+			if layer, err = parseGnvmrc(data); err == nil {
+				layers = append(layers, &Layer{name, path, layer})
+				return
+			}
+		}
+		P(WARING, "read %v config file %v fail, Error: %v\n", name, path, err.Error())
+	}
+
+	addFile(LAYER_SYSTEM, systemConfigPath())
+	layers = append(layers, &Layer{LAYER_INSTALL, configPath, rc})
+	addFile(LAYER_USER, userConfigPath())
+	addFile(LAYER_PROJECT, projectConfigPath(exclude))
+
+	env := new(Gnvmrc)
+	for _, key := range Keys() {
+		if value := os.Getenv(envName(key)); value != "" {
+			*env.field(key) = value
+		}
+	}
+	layers = append(layers, &Layer{LAYER_ENV, "", env})
+}
+
+/*
+ Override config property by CLI flag, usage gnvm -c <key>=<value>
+
+ Param:
+ 	- kv: <key>=<value>
+
+ Return:
+ 	- error
+*/
+func Override(kv string) error {
+	arr := strings.SplitN(kv, "=", 2)
+	if len(arr) != 2 {
+		return errors.New(kv + " format error, must be <key>=<value>.")
+	}
+	key, value := strings.TrimSpace(arr[0]), strings.TrimSpace(arr[1])
+	top := layers[len(layers)-1]
+	if top.Name != LAYER_FLAG {
+		top = &Layer{LAYER_FLAG, "", new(Gnvmrc)}
+		layers = append(layers, top)
+	}
+	field := top.rc.field(key)
+	if field == nil {
+		return errors.New(key + " not a valid config keyword.")
+	}
+	*field = value
+	return nil
+}
+
+/*
+ Return layer of config property effective value
+
+ Param:
+ 	- key: config property
+
+ Return:
+ 	- *Layer: nil is not found, schema key of empty default value is default layer
+*/
+func Origin(key string) *Layer {
+	for i := len(layers) - 1; i >= 0; i-- {
+		if field := layers[i].rc.field(key); field != nil && *field != "" {
+			return layers[i]
+		}
+	}
+	if len(layers) > 0 && layers[0].rc.field(key) != nil {
+		return layers[0]
+	}
+	return nil
+}
+
+/*
+ Return higher layer than install layer which override config property, nil is not overridden
+
+ Param:
+ 	- key: config property
+*/
+func overridden(key string) *Layer {
+	layer := Origin(key)
+	if layer == nil || layer.rc == rc {
+		return nil
+	}
+	for _, v := range layers {
+		if v == layer {
+			// lower than install layer
+			return nil
+		}
+		if v.rc == rc {
+			return layer
+		}
+	}
+	return nil
+}
+
+/*
+ Return effective Gnvmrc, merge all layers
+*/
+func effective() *Gnvmrc {
+	merged := &Gnvmrc{SchemaVersion: rc.SchemaVersion, Registries: map[string]string{}, Templates: map[string]string{}, Unknown: map[string]interface{}{}}
+	for _, layer := range layers {
+		for _, key := range Keys() {
+			if value := *layer.rc.field(key); value != "" {
+				*merged.field(key) = value
+			}
+		}
+		for name, url := range layer.rc.Registries {
+			merged.Registries[name] = url
+		}
+		for name, tmpl := range layer.rc.Templates {
+			merged.Templates[name] = tmpl
+		}
+		for key, value := range layer.rc.Unknown {
+			merged.Unknown[key] = value
+		}
+	}
+	return merged
+}
+
+func envName(key string) string {
+	return ENV_PREFIX + strings.ToUpper(key)
+}
+
+func systemConfigPath() string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			return filepath.Join(dir, "gnvm", CONFIG)
+		}
+		return ""
+	}
+	return filepath.Join("/etc", "gnvm", CONFIG)
+}
+
+func userConfigPath() string {
+	if util.Portable {
+		return ""
+	}
+	home := os.Getenv("USERPROFILE")
+	if home == "" {
+		home = os.Getenv("HOME")
+	}
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, CONFIG)
+}
+
+/*
+ Walking up from the working directory, return first .gnvmrc not in exclude
+*/
+func projectConfigPath(exclude map[string]bool) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, CONFIG)
+		if !exclude[path] && util.IsDirExist(path) {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	// go
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	// local
+	"gnvm/util"
+)
+
+func TestSystemLayerRegistry(t *testing.T) {
+	oldPath, oldRC, oldLayers := configPath, rc, layers
+	defer func() {
+		configPath, rc, layers = oldPath, oldRC, oldLayers
+	}()
+
+	cases := []struct {
+		name    string
+		install string
+	}{
+		{"new", ""},
+		{"migrate", "Registry: " + util.ORIGIN_DEFAULT + "\nglobalversion: " + util.UNKNOWN + "\nlatestversion: " + util.UNKNOWN + "\n"},
+	}
+	for _, c := range cases {
+		configPath = filepath.Join(t.TempDir(), CONFIG)
+		if c.install == "" {
+			createConfig()
+		} else if err := ioutil.WriteFile(configPath, []byte(c.install), 0666); err != nil {
+			t.Fatal(err)
+		}
+		readConfig()
+
+		def := new(Gnvmrc)
+		def.fill()
+		system := &Gnvmrc{Registry: util.ORIGIN_TAOBAO}
+		layers = []*Layer{{LAYER_DEFAULT, "", def}, {LAYER_SYSTEM, "system", system}, {LAYER_INSTALL, configPath, rc}}
+
+		if value := GetConfig(REGISTRY); value != util.ORIGIN_TAOBAO {
+			t.Errorf("%v: GetConfig(%v) = %v, want %v", c.name, REGISTRY, value, util.ORIGIN_TAOBAO)
+		}
+		if layer := Origin(REGISTRY); layer == nil || layer.Name != LAYER_SYSTEM {
+			t.Errorf("%v: Origin(%v) = %v, want %v", c.name, REGISTRY, layer, LAYER_SYSTEM)
+		}
+		if layer := Origin(NODEROOT); layer == nil || layer.Name != LAYER_DEFAULT {
+			t.Errorf("%v: Origin(%v) = %v, want %v", c.name, NODEROOT, layer, LAYER_DEFAULT)
+		}
+	}
+}
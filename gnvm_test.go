@@ -86,3 +86,148 @@ func testVaildPath() {
 	util.FormatPath(&path)
 	fmt.Println(path)
 }
+
+func TestParseDistFile(t *testing.T) {
+	cases := []struct {
+		entry string
+		want  util.DistFile
+	}{
+		{"win-x64-exe", util.DistFile{Entry: "win-x64-exe", OS: "win", Arch: "x64", Kind: "exe"}},
+		{"win-x86-msi", util.DistFile{Entry: "win-x86-msi", OS: "win", Arch: "x86", Kind: "msi"}},
+		{"osx-x64-tar", util.DistFile{Entry: "osx-x64-tar", OS: "osx", Arch: "x64", Kind: "tar"}},
+		{"linux-arm64", util.DistFile{Entry: "linux-arm64", OS: "linux", Arch: "arm64", Kind: ""}},
+		{"headers", util.DistFile{Entry: "headers", OS: "", Arch: "", Kind: "headers"}},
+		{"src", util.DistFile{Entry: "src", OS: "", Arch: "", Kind: "src"}},
+	}
+	for _, c := range cases {
+		if df := util.ParseDistFile(c.entry); df != c.want {
+			t.Errorf("ParseDistFile(%q) = %+v, want %+v", c.entry, df, c.want)
+		}
+	}
+}
+
+func TestDistFileName(t *testing.T) {
+	cases := []struct {
+		entry   string
+		flavor  string
+		version string
+		want    string
+	}{
+		{"win-x64-exe", util.FLAVOR_NODE, "5.9.0", "win-x64/node.exe"},
+		{"win-x86-exe", util.FLAVOR_NODE, "5.9.0", "win-x86/node.exe"},
+		{"win-x86-exe", util.FLAVOR_NODE, "0.12.0", "node.exe"},
+		{"win-x64-exe", util.FLAVOR_NODE, "0.12.0", "x64/node.exe"},
+		{"win-x86-exe", util.FLAVOR_IOJS, "1.0.0", "win-x86/iojs.exe"},
+		{"win-x64-zip", util.FLAVOR_NODE, "5.9.0", "node-v5.9.0-win-x64.zip"},
+		{"win-x64-7z", util.FLAVOR_NODE, "5.9.0", "node-v5.9.0-win-x64.7z"},
+		{"win-x86-msi", util.FLAVOR_NODE, "5.9.0", "node-v5.9.0-x86.msi"},
+		{"osx-x64-tar", util.FLAVOR_NODE, "5.9.0", "node-v5.9.0-darwin-x64.tar.gz"},
+		{"osx-x64-pkg", util.FLAVOR_NODE, "5.9.0", "node-v5.9.0.pkg"},
+		{"linux-arm64", util.FLAVOR_NODE, "5.9.0", "node-v5.9.0-linux-arm64.tar.gz"},
+		{"linux-x64", util.FLAVOR_IOJS, "3.3.1", "iojs-v3.3.1-linux-x64.tar.gz"},
+		{"headers", util.FLAVOR_NODE, "5.9.0", "node-v5.9.0-headers.tar.gz"},
+		{"src", util.FLAVOR_NODE, "5.9.0", "node-v5.9.0.tar.gz"},
+		{"win-x64-deb", util.FLAVOR_NODE, "5.9.0", ""},
+	}
+	for _, c := range cases {
+		if name := util.ParseDistFile(c.entry).Name(c.flavor, c.version); name != c.want {
+			t.Errorf("ParseDistFile(%q).Name(%q, %q) = %q, want %q", c.entry, c.flavor, c.version, name, c.want)
+		}
+	}
+}
+
+func TestDistFileURL(t *testing.T) {
+	tmpl := "{base}/{version}/{flavor}-{semver}-{os}-{arch}.{ext}"
+	cases := []struct {
+		entry   string
+		tmpl    string
+		url     string
+		flavor  string
+		version string
+		want    string
+	}{
+		{"win-x64-exe", "", util.ORIGIN_DEFAULT, util.FLAVOR_NODE, "5.9.0", util.ORIGIN_DEFAULT + "v5.9.0/win-x64/node.exe"},
+		{"win-x86-exe", "", util.IOJS_DEFAULT, util.FLAVOR_IOJS, "1.0.0", util.IOJS_DEFAULT + "v1.0.0/win-x86/iojs.exe"},
+		{"win-x64-exe", tmpl, "https://bucket/node/", util.FLAVOR_NODE, "18.19.0", "https://bucket/node/v18.19.0/node-18.19.0-win-x64.exe"},
+		{"linux-arm64", tmpl, "https://bucket/node", util.FLAVOR_NODE, "18.19.0", "https://bucket/node/v18.19.0/node-18.19.0-linux-arm64.tar.gz"},
+		{"osx-x64-tar", tmpl, "https://bucket/node/", util.FLAVOR_NODE, "18.19.0", "https://bucket/node/v18.19.0/node-18.19.0-osx-x64.tar.gz"},
+	}
+	for _, c := range cases {
+		if url := util.ParseDistFile(c.entry).URL(c.tmpl, c.url, c.flavor, c.version); url != c.want {
+			t.Errorf("ParseDistFile(%q).URL(%q, %q, %q, %q) = %q, want %q", c.entry, c.tmpl, c.url, c.flavor, c.version, url, c.want)
+		}
+	}
+}
+
+func TestVerifyDistFile(t *testing.T) {
+	files := []string{"headers", "linux-arm64", "osx-x64-tar", "src", "win-x64-exe", "win-x64-zip", "win-x86-msi"}
+	cases := []struct {
+		files []string
+		entry string
+		err   string
+	}{
+		{files, "win-x64-exe", ""},
+		{files, "linux-arm64", ""},
+		{files, "win-x86-exe", "version 5.9.0 not provide win-x86-exe, available: win-x64-exe win-x64-zip win-x86-msi"},
+		{files, "win-arm64-exe", "version 5.9.0 not provide win-arm64-exe, available: win-x64-exe win-x64-zip win-x86-msi"},
+		{files, "linux-ppc64le", "version 5.9.0 not provide linux-ppc64le, available: linux-arm64"},
+		{files, "sunos-x64", "version 5.9.0 not provide sunos-x64, not any sunos file."},
+		{[]string{"src"}, "win-x64-exe", "version 5.9.0 not provide win-x64-exe, not any win file."},
+	}
+	for _, c := range cases {
+		err, msg := util.VerifyDistFile("5.9.0", c.files, c.entry), ""
+		if err != nil {
+			msg = err.Error()
+		}
+		if msg != c.err {
+			t.Errorf("VerifyDistFile(%q, %v) error = %q, want %q", c.entry, c.files, msg, c.err)
+		}
+	}
+}
+
+func TestMatchRange(t *testing.T) {
+	cases := []struct {
+		version string
+		r       string
+		match   bool
+		err     bool
+	}{
+		{"18.19.0", "18.19.0", true, false},
+		{"18.19.0", "18.19.1", false, false},
+		{"18.19.0", "18.*.*", true, false},
+		{"18.19.0", "18.x", true, false},
+		{"18.19.0", "18", true, false},
+		{"18.19.0", "v18.19", true, false},
+		{"18.19.0", "16", false, false},
+		{"18.19.0", ">=18.0.0", true, false},
+		{"18.19.0", ">=16.0.0 <18.0.0", false, false},
+		{"16.20.2", ">=16.0.0 <18.0.0", true, false},
+		{"18.19.0", "<18", false, false},
+		{"17.9.1", "<18", true, false},
+		{"20.5.1", "<=20", true, false},
+		{"20.0.0", "<=20", true, false},
+		{"21.0.0", "<=20", false, false},
+		{"20.5.1", ">20", false, false},
+		{"21.0.0", ">20", true, false},
+		{"20.0.0", ">=20", true, false},
+		{"19.9.0", ">=20", false, false},
+		{"20.1.9", ">20.1", false, false},
+		{"20.2.0", ">20.1", true, false},
+		{"20.1.9", "<=20.1", true, false},
+		{"20.2.0", "<=20.1", false, false},
+		{"20.1.5", ">20.1.4 <=20.1.5", true, false},
+		{"20.1.6", ">20.1.4 <=20.1.5", false, false},
+		{"18.19.0-x86", ">=18 <19", true, false},
+		{"18.19.0", "", false, true},
+		{"18.19.0", "~18", false, true},
+		{"18.19.0", ">=18.*", false, true},
+	}
+	for _, c := range cases {
+		match, err := util.MatchRange(c.version, c.r)
+		if (err != nil) != c.err {
+			t.Errorf("MatchRange(%q, %q) error = %v, want error %v", c.version, c.r, err, c.err)
+		} else if match != c.match {
+			t.Errorf("MatchRange(%q, %q) = %v, want %v", c.version, c.r, match, c.match)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	// local
+	"gnvm/config"
+	"gnvm/util"
+)
+
+const (
+	CHECK_OK       = "ok"
+	CHECK_MISSING  = "missing"
+	CHECK_MISMATCH = "mismatch"
+	CHECK_ERROR    = "error"
+	CHECK_SKIP     = "skip"
+)
+
+/*
+ Check registry conformance, HEAD every artifact gnvm would fetch, usage 'gnvm registry check'
+
+ Param:
+ 	- version: Node.js version, e.g. 18.19.0, empty is remote latest version
+ 	- arch:    x86 x64, empty is current arch
+ 	- timeout: probe timeout of all artifacts
+
+ Return:
+ 	- true( all artifacts available ) false( has missing or mismatched )
+*/
+func RegistryCheck(version, arch string, timeout time.Duration) bool {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm registry check' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	registry := config.GetConfig(config.REGISTRY)
+	if arch == "" {
+		arch = util.DistArch(runtime.GOARCH)
+	}
+	if arch != "x86" && arch != "x64" {
+		P(ERROR, "%v only support [%v] or [%v]. See '%v'.\n", "--arch", "x86", "x64", "gnvm help registry")
+		return false
+	}
+	if version == "" || version == util.LATEST {
+		if version = util.GetLatVer(latURL()); version == "" {
+			P(ERROR, "get remote latest version from %v fail. See '%v'.\n", util.Redact(latURL()), "gnvm registry bench")
+			return false
+		}
+	}
+	if !util.VerifyNodeVer(version) {
+		P(ERROR, "%v format error, the correct format is %v. See '%v'.\n", version, "x.xx.xx", "gnvm help registry")
+		return false
+	}
+
+	list := &CheckList{Registry: registry, Version: version, Arch: arch, OK: true, Artifacts: []CheckItem{}}
+	if !util.IsMachine() {
+		P(NOTICE, "check registry %v with Node.js version %v arch %v.\n", util.Redact(registry), version, arch)
+	}
+
+	// node or io.js artifacts, flavor is which index listed the version, url template only usage registry
+	ioURL := config.GetConfig(config.IOJS_REGISTRY)
+	if flavor, _, _, _ := findRelease(registry, version); flavor == util.FLAVOR_IOJS {
+		list.Artifacts = append(list.Artifacts, artifacts(ioURL, "", flavor, version, arch)...)
+	} else {
+		list.Artifacts = append(list.Artifacts, artifacts(registry, config.GetConfig(config.URL_TEMPLATE), util.FLAVOR_NODE, version, arch)...)
+
+		// io.js equivalents, usage latest io.js version
+		if ioURL == registry {
+			list.Artifacts = append(list.Artifacts, CheckItem{Name: util.FLAVOR_IOJS, URL: registry, Size: -1, Status: CHECK_SKIP, Message: "registry not provide io.js mirror."})
+		} else if nodist, err, _ := New(ioURL+util.NODELIST, nil); err != nil {
+			list.Artifacts = append(list.Artifacts, CheckItem{Name: util.FLAVOR_IOJS + " " + util.NODELIST, URL: ioURL + util.NODELIST, Size: -1, Status: CHECK_ERROR, Message: util.Redact(err.Error())})
+		} else if len(nodist.Sorts) > 0 {
+			list.Artifacts = append(list.Artifacts, artifacts(ioURL, "", util.FLAVOR_IOJS, nodist.Sorts[0][1:], arch)...)
+		}
+	}
+
+	// HEAD all artifacts concurrently
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for idx := range list.Artifacts {
+		if list.Artifacts[idx].Status != "" {
+			continue
+		}
+		wg.Add(1)
+		go func(item *CheckItem) {
+			defer wg.Done()
+			checkArtifact(ctx, item)
+		}(&list.Artifacts[idx])
+	}
+	wg.Wait()
+
+	problems := 0
+	for _, item := range list.Artifacts {
+		if item.Status != CHECK_OK && item.Status != CHECK_SKIP {
+			problems++
+		}
+	}
+	list.OK = problems == 0
+
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm registry check", err.Error())
+		}
+		return list.OK
+	}
+
+	for _, item := range list.Artifacts {
+		status := fmt.Sprintf("%-8v", item.Status)
+		switch item.Status {
+		case CHECK_OK:
+			P(DEFAULT, "%v %v %v\n", CP{Green, false, None, false, status}, item.Name, util.Redact(item.URL))
+		case CHECK_SKIP:
+			P(DEFAULT, "%v %v %v %v\n", status, item.Name, util.Redact(item.URL), item.Message)
+		default:
+			P(DEFAULT, "%v %v %v %v\n", CP{Red, false, None, false, status}, item.Name, util.Redact(item.URL), item.Message)
+		}
+	}
+	if list.OK {
+		P(DEFAULT, "registry %v check %v, all %v artifacts available.\n", util.Redact(registry), "pass", len(list.Artifacts))
+	} else {
+		P(WARING, "registry %v check fail, found %v problems in %v artifacts.\n", util.Redact(registry), problems, len(list.Artifacts))
+	}
+	return list.OK
+}
+
+/*
+ Return artifacts of version, include: node.exe, SHASUMS256.txt and signature, zip, npm zip
+
+ Param:
+ 	- url:     registry url, e.g. http://nodejs.org/dist/
+ 	- tmpl:    download url template of registry, empty is nodejs.org layout, SHASUMS256.txt and signature always usage nodejs.org layout
+ 	- flavor:  FLAVOR_NODE or FLAVOR_IOJS, which index listed the version
+ 	- version: Node.js or io.js version
+ 	- arch:    x86 x64
+
+ Return:
+ 	- artifact collection, index error or version not found is status CHECK_ERROR or CHECK_MISSING
+*/
+func artifacts(url, tmpl, flavor, version, arch string) []CheckItem {
+	items := []CheckItem{}
+	nodist, err, _ := New(url+util.NODELIST, nil)
+	if err != nil {
+		return append(items, CheckItem{Name: flavor + " " + util.NODELIST, URL: url + util.NODELIST, Size: -1, Status: CHECK_ERROR, Message: util.Redact(err.Error())})
+	}
+	nd, ok := nodist.nl["v"+version]
+	if !ok {
+		return append(items, CheckItem{Name: flavor + " " + util.NODELIST, URL: url + util.NODELIST, Size: -1, Status: CHECK_MISSING, Message: "version " + version + " not found in index."})
+	}
+
+	root, goarch := url+"v"+version+"/", "amd64"
+	if arch == "x86" {
+		goarch = "386"
+	}
+	listed := func(entry string) string {
+		switch {
+		case nd.Files == nil:
+			return "unknown"
+		case util.HasDistFile(nd.Files, entry):
+			return "yes"
+		}
+		return "no"
+	}
+
+	exe := util.DistEntry("windows", goarch, "exe")
+	zip := util.DistEntry("windows", goarch, "zip")
+	items = append(items,
+		CheckItem{Name: flavor + " " + exe, URL: util.ParseDistFile(exe).URL(tmpl, url, flavor, version), Listed: listed(exe)},
+		CheckItem{Name: flavor + " " + util.SHASUMS, URL: root + util.SHASUMS},
+		CheckItem{Name: flavor + " " + util.SHASUMS + ".sig", URL: root + util.SHASUMS + ".sig"},
+		CheckItem{Name: flavor + " " + util.SHASUMS + ".asc", URL: root + util.SHASUMS + ".asc"},
+		CheckItem{Name: flavor + " " + zip, URL: util.ParseDistFile(zip).URL(tmpl, url, flavor, version), Listed: listed(zip)},
+	)
+	if nd.NPM.Version != "[x]" {
+		items = append(items, CheckItem{Name: util.NPM + " " + nd.NPM.Version, URL: npmURL(nd.NPM.Version)})
+	} else {
+		items = append(items, CheckItem{Name: util.NPM, URL: url + util.NODELIST, Size: -1, Status: CHECK_SKIP, Message: "index not provide npm version of " + version + "."})
+	}
+	return items
+}
+
+/*
+ HEAD artifact and set status
+
+ - ok:       200 and listed in index( or index not provide files )
+ - missing:  404
+ - mismatch: 200 but not listed in index, or empty file
+ - error:    request fail or other response code
+*/
+func checkArtifact(ctx context.Context, item *CheckItem) {
+	result := util.Probe(ctx, http.MethodHead, item.URL)
+	item.Code, item.Size = result.Code, result.Size
+	switch {
+	case result.Code == 0:
+		item.Status, item.Message = CHECK_ERROR, util.Redact(result.Err.Error())
+	case result.Code == http.StatusNotFound:
+		item.Status, item.Message = CHECK_MISSING, "not found."
+		if item.Listed == "yes" {
+			item.Message = "listed in index files but not found."
+		}
+	case result.Code != http.StatusOK:
+		item.Status, item.Message = CHECK_ERROR, util.Redact(result.Err.Error())
+	case item.Listed == "no":
+		item.Status, item.Message = CHECK_MISMATCH, "available but not listed in index files."
+	case result.Size == 0:
+		item.Status, item.Message = CHECK_MISMATCH, "empty file."
+	default:
+		item.Status = CHECK_OK
+	}
+}
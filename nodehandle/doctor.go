@@ -0,0 +1,368 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	// local
+	"gnvm/config"
+	"gnvm/util"
+)
+
+const (
+	SEVERITY_OK      = "ok"
+	SEVERITY_INFO    = "info"
+	SEVERITY_WARNING = "warning"
+	SEVERITY_ERROR   = "error"
+)
+
+/*
+ Diagnostics collection, repair is safe repair of item, nil is only suggestion
+*/
+type doctor struct {
+	list    *DoctorList
+	repairs []func() error
+}
+
+func (this *doctor) add(check, severity, message, fix string, repair func() error) {
+	this.list.Items = append(this.list.Items, DoctorItem{Check: check, Severity: severity, Message: message, Fix: fix})
+	this.repairs = append(this.repairs, repair)
+}
+
+/*
+ Check environment and installation, usage 'gnvm doctor'
+
+ - path:     PATH include noderoot before other node.exe folder
+ - nodehome: NODE_HOME match noderoot
+ - global:   globalversion match <root>/node.exe version
+ - folder:   orphan, corrupt and legacy layout version folders
+ - latest:   latestversion folder exist
+ - session:  stale gns.cmd
+ - npmzip:   leftover npm zip of 'gnvm npm'
+ - npm:      npm version match Node.js version
+
+ Param:
+ 	- fix: true( apply safe repairs )
+
+ Return:
+ 	- true( not any error ) false( has unfixed error )
+*/
+func Doctor(fix bool) bool {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm doctor' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	dr := &doctor{list: &DoctorList{Fix: fix, Items: []DoctorItem{}}}
+	root := filepath.Clean(rootPath)
+
+	dr.checkPath(root)
+	dr.checkNodeHome(root)
+	dr.checkGlobal()
+	dr.checkFolders()
+	dr.checkLatest()
+	dr.checkSession()
+	dr.checkNPMZip()
+	dr.checkNPM()
+
+	ok := true
+	for idx := range dr.list.Items {
+		item := &dr.list.Items[idx]
+		if fix && dr.repairs[idx] != nil {
+			if err := dr.repairs[idx](); err != nil {
+				item.Message += " repair Error: " + err.Error()
+			} else {
+				item.Fixed = true
+			}
+		}
+		if item.Severity == SEVERITY_ERROR && !item.Fixed {
+			ok = false
+		}
+	}
+	dr.list.OK = ok
+
+	if util.IsMachine() {
+		if err := util.Render(dr.list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm doctor", err.Error())
+		}
+		return ok
+	}
+
+	errors, warnings, fixable, fixed := 0, 0, 0, 0
+	for idx, item := range dr.list.Items {
+		label := fmt.Sprintf("%-7v", item.Severity)
+		var severity interface{} = label
+		switch item.Severity {
+		case SEVERITY_ERROR:
+			severity = CP{Red, false, None, false, label}
+		case SEVERITY_WARNING:
+			severity = CP{Yellow, false, None, false, label}
+		case SEVERITY_OK:
+			severity = CP{Green, false, None, false, label}
+		}
+		P(DEFAULT, "[%v] %-8v %v\n", severity, item.Check, item.Message)
+		switch {
+		case item.Fixed:
+		case item.Severity == SEVERITY_ERROR:
+			errors++
+		case item.Severity == SEVERITY_WARNING:
+			warnings++
+		}
+		switch {
+		case item.Fixed:
+			fixed++
+			P(DEFAULT, "%v fixed, %v\n", strings.Repeat(" ", 20), item.Fix)
+		case item.Fix != "":
+			if dr.repairs[idx] != nil {
+				fixable++
+			}
+			P(DEFAULT, "%v %v\n", strings.Repeat(" ", 20), item.Fix)
+		}
+	}
+
+	P(DEFAULT, "Summary: %v errors, %v warnings, %v fixed.\n", errors, warnings, fixed)
+	if fixable > 0 {
+		P(NOTICE, "%v findings can be repaired automatically, please use '%v'.\n", fixable, "gnvm doctor --fix")
+	}
+	return ok
+}
+
+/*
+ PATH include noderoot and not any other node.exe folder before it
+*/
+func (this *doctor) checkPath(root string) {
+	shadow, found := "", false
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		dir = strings.Trim(dir, `"`)
+		if dir == "" {
+			continue
+		}
+		if samePath(dir, root) {
+			found = true
+			break
+		}
+		if shadow == "" && util.IsDirExist(dir, util.NODE) {
+			shadow = dir
+		}
+	}
+	switch {
+	case !found:
+		this.add("path", SEVERITY_WARNING, fmt.Sprintf("noderoot %v not in PATH.", root), "please use 'gnvm env' or 'gnvm reg noderoot'.", nil)
+	case shadow != "":
+		this.add("path", SEVERITY_ERROR, fmt.Sprintf("%v of %v is before noderoot %v in PATH.", util.NODE, shadow, root), "please move "+root+" before "+shadow+" in PATH, or uninstall other Node.js.", nil)
+	default:
+		this.add("path", SEVERITY_OK, fmt.Sprintf("noderoot %v is the first %v folder in PATH.", root, util.NODE), "", nil)
+	}
+}
+
+/*
+ NODE_HOME match noderoot
+*/
+func (this *doctor) checkNodeHome(root string) {
+	env := os.Getenv(NODE_HOME)
+	switch {
+	case env == "":
+		this.add("nodehome", SEVERITY_INFO, fmt.Sprintf("environment variable %v not set.", NODE_HOME), "please use 'gnvm env' or 'gnvm reg noderoot'.", nil)
+	case !samePath(env, root):
+		this.add("nodehome", SEVERITY_WARNING, fmt.Sprintf("%v is %v, not match noderoot %v.", NODE_HOME, env, root), "please use 'gnvm env' or 'gnvm reg noderoot'.", nil)
+	default:
+		this.add("nodehome", SEVERITY_OK, fmt.Sprintf("%v match noderoot %v.", NODE_HOME, root), "", nil)
+	}
+}
+
+/*
+ globalversion match node --version of <root>/node.exe
+*/
+func (this *doctor) checkGlobal() {
+	global := config.GetConfig(config.GLOBAL_VERSION)
+	real, err := globalVersion()
+	setGlobal := func(value string) func() error {
+		return func() error {
+			if config.SetConfig(config.GLOBAL_VERSION, value) == "" {
+				return fmt.Errorf("set %v fail.", config.GLOBAL_VERSION)
+			}
+			return nil
+		}
+	}
+	switch {
+	case err != nil && global == util.UNKNOWN:
+		this.add("global", SEVERITY_INFO, fmt.Sprintf("not found global %v in %v.", util.NODE, rootPath), "please use 'gnvm install latest -g'.", nil)
+	case err != nil:
+		this.add("global", SEVERITY_WARNING, fmt.Sprintf("globalversion is %v, but not found %v in %v.", global, util.NODE, rootPath), "set globalversion to "+util.UNKNOWN+".", setGlobal(util.UNKNOWN))
+	case real != global:
+		this.add("global", SEVERITY_WARNING, fmt.Sprintf("globalversion is %v, not match node --version %v.", global, real), "set globalversion to "+real+".", setGlobal(real))
+	default:
+		this.add("global", SEVERITY_OK, fmt.Sprintf("globalversion %v match node --version.", global), "", nil)
+	}
+}
+
+/*
+ latestversion folder exist, repair is newest installed version
+*/
+func (this *doctor) checkLatest() {
+	latest := config.GetConfig(config.LATEST_VERSION)
+	if latest == util.UNKNOWN {
+		this.add("latest", SEVERITY_INFO, "latestversion is "+util.UNKNOWN+".", "please use 'gnvm update latest'.", nil)
+		return
+	}
+	if util.IsDirExist(util.VersionPath(latest), util.NODE) {
+		this.add("latest", SEVERITY_OK, fmt.Sprintf("latestversion %v folder exist.", latest), "", nil)
+		return
+	}
+	newest := util.UNKNOWN
+	if versions, err := util.InstalledVersions(); err == nil {
+		for _, v := range versions {
+			// skip corrupt folder
+			if info, err := os.Stat(filepath.Join(util.VersionPath(v), util.NODE)); err != nil || info.Size() == 0 {
+				continue
+			}
+			if !strings.Contains(v, "-") && (newest == util.UNKNOWN || util.CompareNodeVer(v, newest) > 0) {
+				newest = v
+			}
+		}
+	}
+	this.add("latest", SEVERITY_WARNING, fmt.Sprintf("latestversion is %v, but folder %v not exist.", latest, util.VersionPath(latest)), "set latestversion to "+newest+".", func() error {
+		if config.SetConfig(config.LATEST_VERSION, newest) == "" {
+			return fmt.Errorf("set %v fail.", config.LATEST_VERSION)
+		}
+		return nil
+	})
+}
+
+/*
+ Orphan, corrupt and legacy layout version folders, repair is remove corrupt folder( not include node.exe )
+*/
+func (this *doctor) checkFolders() {
+	if flat := flatFolders(""); len(flat) > 0 {
+		this.add("folder", SEVERITY_WARNING, fmt.Sprintf("found %v version folders in legacy layout.", len(flat)), "please use 'gnvm migrate'.", nil)
+	}
+
+	count, issues := 0, len(this.list.Items)
+	files, _ := ioutil.ReadDir(util.VersionsPath)
+	for _, file := range files {
+		if !file.IsDir() || (file.Name() != util.FLAVOR_NODE && file.Name() != util.FLAVOR_IOJS) {
+			this.add("folder", SEVERITY_WARNING, fmt.Sprintf("orphan %v in %v.", file.Name(), util.VersionsPath), "please check and remove it.", nil)
+			continue
+		}
+		flavor := file.Name()
+		folders, _ := ioutil.ReadDir(filepath.Join(util.VersionsPath, flavor))
+		for _, folder := range folders {
+			path := filepath.Join(util.VersionsPath, flavor, folder.Name())
+			arr := strings.SplitN(folder.Name(), "-", 2)
+			if !folder.IsDir() || len(arr) != 2 || !util.VerifyNodeVer(arr[0]) || (arr[1] != "x86" && arr[1] != "x64") {
+				this.add("folder", SEVERITY_WARNING, fmt.Sprintf("orphan %v, not a valid %v version folder.", path, flavor), "please check and remove it.", nil)
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(path, util.NODE)); err != nil || info.Size() == 0 {
+				this.add("folder", SEVERITY_ERROR, fmt.Sprintf("corrupt %v, %v not exist or empty.", path, util.NODE), "remove folder "+path+".", func() error {
+					return os.RemoveAll(path)
+				})
+				continue
+			}
+			count++
+		}
+	}
+	if len(this.list.Items) == issues {
+		this.add("folder", SEVERITY_OK, fmt.Sprintf("%v version folders in %v.", count, util.VersionsPath), "", nil)
+	}
+}
+
+/*
+ gns.cmd is generated by current gnvm, repair is re-create it
+*/
+func (this *doctor) checkSession() {
+	data, err := ioutil.ReadFile(GNS_HOME)
+	if err != nil {
+		return
+	}
+	content := gnsContent()
+	if string(data) == content {
+		this.add("session", SEVERITY_OK, fmt.Sprintf("%v is up to date.", GNS_HOME), "", nil)
+		return
+	}
+	this.add("session", SEVERITY_WARNING, fmt.Sprintf("%v is stale.", GNS_HOME), "re-create "+GNS+".", func() error {
+		return util.WriteFile(GNS_HOME, []byte(content), 0777)
+	})
+}
+
+/*
+ Leftover npm zip of interrupted 'gnvm npm', repair is remove it
+*/
+func (this *doctor) checkNPMZip() {
+	files, _ := ioutil.ReadDir(rootPath)
+	reg := regexp.MustCompile(`^v\d+\.\d+\.\d+\` + ZIP + `$`)
+	for _, file := range files {
+		if file.IsDir() || !reg.MatchString(file.Name()) {
+			continue
+		}
+		path := filepath.Join(rootPath, file.Name())
+		this.add("npmzip", SEVERITY_WARNING, fmt.Sprintf("leftover npm zip %v.", path), "remove file "+path+".", func() error {
+			return os.Remove(path)
+		})
+	}
+}
+
+/*
+ Local npm version match npm version of global Node.js release
+*/
+func (this *doctor) checkNPM() {
+	if _, err := util.GetNodeVer(rootPath); err != nil {
+		return
+	}
+	out, err := exec.Command(rootPath+util.NPM, "-v").Output()
+	if err != nil {
+		this.add("npm", SEVERITY_WARNING, fmt.Sprintf("not found npm in %v.", rootPath), "please use 'gnvm npm global'.", nil)
+		return
+	}
+	local := strings.TrimSpace(string(out))
+	expect, err := nodeNPMVer()
+	switch {
+	case err != nil:
+		this.add("npm", SEVERITY_INFO, fmt.Sprintf("npm version is %v, skip check, Error: %v", local, util.Redact(err.Error())), "", nil)
+	case expect != local:
+		this.add("npm", SEVERITY_WARNING, fmt.Sprintf("npm version is %v, not match Node.js release npm version %v.", local, expect), "please use 'gnvm npm global'.", nil)
+	default:
+		this.add("npm", SEVERITY_OK, fmt.Sprintf("npm version %v match Node.js release.", local), "", nil)
+	}
+}
+
+/*
+ Return npm version of global Node.js release, getNodeNpmVer panic is error
+*/
+func nodeNPMVer() (ver string, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	ver = getNodeNpmVer()
+	if ver == "" {
+		err = fmt.Errorf("not found npm version of Node.js release.")
+	}
+	return ver, err
+}
+
+/*
+ Compare path, ignore case on Windows
+*/
+func samePath(p1, p2 string) bool {
+	p1, p2 = filepath.Clean(p1), filepath.Clean(p2)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(p1, p2)
+	}
+	return p1 == p2
+}
@@ -0,0 +1,85 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// local
+	"gnvm/util"
+)
+
+const (
+	SHELL_CMD        = "cmd"
+	SHELL_POWERSHELL = "powershell"
+)
+
+/*
+ Print environment variables of current gnvm layout, usage 'gnvm env', e.g. in cmd:
+ 	for /f "delims=" %i in ('gnvm env') do %i
+
+ Only variable assignments print to real stdout, messages print to stderr, otherwise shell run them.
+
+ - NODE_HOME:         global node.exe folder
+ - GNVM_HOME:         only when gnvm data folder is set
+ - NPM_CONFIG_PREFIX: portable mode, npm -g install to global node.exe folder, not %APPDATA%\npm
+ - NPM_CONFIG_CACHE:  portable mode, npm cache in <home>\cache\npm, not %LOCALAPPDATA%\npm-cache
+ - PATH:              gnvm.exe and global node.exe folder, prepend to current PATH
+
+ Param:
+ 	- shell: cmd powershell
+
+ Return:
+ 	- true( success ) false( shell not support or render error )
+*/
+func Env(shell string) bool {
+	if shell != SHELL_CMD && shell != SHELL_POWERSHELL {
+		P(ERROR, "%v only support [%v] or [%v], please check your input. See '%v'.\n", "--shell", SHELL_CMD, SHELL_POWERSHELL, "gnvm help env")
+		return false
+	}
+
+	root := filepath.Clean(rootPath)
+	list := &EnvList{Home: util.HomePath, Portable: util.Portable, Vars: []EnvVar{}}
+	if util.GnvmHome != "" {
+		list.Vars = append(list.Vars, EnvVar{util.HOME_ENV, util.GnvmHome})
+	}
+	list.Vars = append(list.Vars, EnvVar{NODE_HOME, root})
+	if util.Portable {
+		list.Vars = append(list.Vars, EnvVar{"NPM_CONFIG_PREFIX", root}, EnvVar{"NPM_CONFIG_CACHE", filepath.Join(util.CachePath, "npm")})
+	}
+
+	paths := []string{}
+	for _, path := range []string{util.ExeDir(), root} {
+		if path != "" && (len(paths) == 0 || paths[len(paths)-1] != path) {
+			paths = append(paths, path)
+		}
+	}
+	list.Vars = append(list.Vars, EnvVar{PATH, strings.Join(paths, string(os.PathListSeparator))})
+
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm env", err.Error())
+			return false
+		}
+		return true
+	}
+
+	for _, v := range list.Vars {
+		switch {
+		case shell == SHELL_POWERSHELL && v.Name == PATH:
+			fmt.Fprintf(util.Stdout, "$env:%v = \"%v%c\" + $env:%v\n", v.Name, v.Value, os.PathListSeparator, v.Name)
+		case shell == SHELL_POWERSHELL:
+			fmt.Fprintf(util.Stdout, "$env:%v = \"%v\"\n", v.Name, v.Value)
+		case v.Name == PATH:
+			fmt.Fprintf(util.Stdout, "set \"%v=%v%c%%%v%%\"\n", v.Name, v.Value, os.PathListSeparator, v.Name)
+		default:
+			fmt.Fprintf(util.Stdout, "set \"%v=%v\"\n", v.Name, v.Value)
+		}
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	// local
+	"gnvm/config"
+	"gnvm/util"
+)
+
+/*
+ Run command under installed version without change global node.exe, usage 'gnvm exec' and 'gnvm run', e.g.
+ 	gnvm exec 16.20.2 -- npm test
+ 	gnvm run 18 app.js
+
+ Child environment:
+ 	- PATH:      version folder first, then global node.exe folder for npm, then current PATH
+ 	- NODE_HOME: version folder
+ 	- npm npx:   when version folder not include npm, run <root>\node_modules\npm\bin\npm-cli.js by version node.exe
+
+ Param:
+ 	- version: installed version, include: x.xx.xx x.xx.xx-x86 latest and partial version, e.g. 18 18.19 18-x86
+ 	- args:    command and arguments, e.g. [npm test]
+
+ Return:
+ 	- exit code of command, 1 is gnvm error
+*/
+func Exec(version string, args []string) int {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm exec "+version+"' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	if len(args) == 0 {
+		P(ERROR, "%v need command, please check your input. See '%v'.\n", "gnvm exec "+version, "gnvm help exec")
+		return 1
+	}
+
+	name, err := resolveVersion(version)
+	if err != nil {
+		P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm ls")
+		return 1
+	}
+	if err := util.PolicyVersion(name); err != nil {
+		P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm policy")
+		return 1
+	}
+
+	cmd, err := execCommand(name, args)
+	if err != nil {
+		P(ERROR, "run %v Error: %v\n", args[0], err.Error())
+		return 1
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, util.Stdout, os.Stderr
+
+	// Ctrl+C is sent to command, gnvm wait command exit
+	signal.Ignore(os.Interrupt)
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() > 0 {
+			return exitErr.ExitCode()
+		}
+		P(ERROR, "run %v Error: %v\n", args[0], err.Error())
+		return 1
+	}
+	return 0
+}
+
+/*
+ Create command of installed version, child environment is independent of current process, usage 'gnvm exec' and 'gnvm matrix'
+
+ Param:
+ 	- name: installed version name, e.g. 18.19.0 18.19.0-x86
+ 	- args: command and arguments, e.g. [npm test]
+
+ Return:
+ 	- cmd:   stdio not set
+ 	- error: command not found
+*/
+func execCommand(name string, args []string) (*exec.Cmd, error) {
+	return nodeCommand(util.VersionPath(name), args)
+}
+
+/*
+ Create command of node.exe folder, folder is first in PATH and NODE_HOME is folder
+
+ Param:
+ 	- path: node.exe folder, version folder or global node.exe folder
+ 	- args: command and arguments, e.g. [npm prefix -g]
+
+ Return:
+ 	- cmd:   stdio not set
+ 	- error: command not found
+*/
+func nodeCommand(path string, args []string) (*exec.Cmd, error) {
+	paths := []string{path}
+	if root := filepath.Clean(rootPath); root != path {
+		paths = append(paths, root)
+	}
+	paths = append(paths, filepath.SplitList(os.Getenv("PATH"))...)
+
+	// npm of global node.exe folder run by global node.exe, replace with version node.exe
+	command := args[0]
+	if cli := npmCLI(path, strings.TrimSuffix(strings.ToLower(command), ".cmd")); cli != "" {
+		command, args = filepath.Join(path, util.NODE), append([]string{command, cli}, args[1:]...)
+	}
+
+	// find command in child PATH, not current PATH
+	if !strings.ContainsAny(command, `/\`) {
+		found := ""
+		for _, dir := range paths {
+			if filepath.IsAbs(dir) {
+				if file, err := exec.LookPath(filepath.Join(dir, command)); err == nil {
+					found = file
+					break
+				}
+			}
+		}
+		if found == "" {
+			return nil, errors.New("exec: \"" + command + "\": executable file not found in PATH")
+		}
+		command = found
+	}
+
+	cmd := exec.Command(command, args[1:]...)
+	cmd.Env = []string{}
+	for _, v := range os.Environ() {
+		key := strings.SplitN(v, "=", 2)[0]
+		if !strings.EqualFold(key, "PATH") && !strings.EqualFold(key, NODE_HOME) {
+			cmd.Env = append(cmd.Env, v)
+		}
+	}
+	cmd.Env = append(cmd.Env, "PATH="+strings.Join(paths, string(os.PathListSeparator)), NODE_HOME+"="+path)
+	return cmd, nil
+}
+
+/*
+ Return installed version name, partial version is newest installed version of it
+
+ Param:
+ 	- version: x.xx.xx x.xx.xx-x86 latest 18 18.19 18-x86
+
+ Return:
+ 	- version name, e.g. 18.19.0 18.19.0-x86
+ 	- error
+*/
+func resolveVersion(version string) (string, error) {
+	version = util.EqualAbs("latest", version)
+	if version == util.LATEST {
+		util.FormatLatVer(&version, config.GetConfig(config.LATEST_VERSION), false)
+		if version == util.UNKNOWN {
+			return "", errors.New("current latest is " + util.UNKNOWN + ", please usage 'gnvm update latest' first.")
+		}
+	}
+
+	if ver := strings.Split(version, "-")[0]; strings.Count(ver, ".") == 2 && util.VerifyNodeVer(ver) {
+		if _, _, _, err := util.ParseNodeVer(version); err != nil {
+			return "", errors.New(version + " format error, e.g. 18.19.0 18.19.0-x86 18 latest.")
+		}
+		name := util.CanonicalName(version)
+		if !util.IsDirExist(util.VersionPath(name), util.NODE) {
+			return "", errors.New(name + " is not installed.")
+		}
+		return name, nil
+	}
+
+	// partial version, e.g. 18 18.19 18-x86
+	match := regexp.MustCompile(`^(\d+(?:\.\d+)?)(?:-(x86|x64))?$`).FindStringSubmatch(strings.ToLower(version))
+	if match == nil {
+		return "", errors.New(version + " format error, e.g. 18.19.0 18.19.0-x86 18 latest.")
+	}
+	arch := match[2]
+	if arch == "" {
+		arch = util.DistArch(runtime.GOARCH)
+	}
+	versions, err := util.InstalledVersions()
+	if err != nil {
+		return "", err
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return util.CompareNodeVer(versions[i], versions[j]) > 0
+	})
+	for _, name := range versions {
+		ver, goarch, _, _ := util.ParseNodeVer(name)
+		if ok, _ := util.MatchRange(ver, match[1]); ok && util.DistArch(goarch) == arch {
+			return name, nil
+		}
+	}
+	return "", errors.New("not found installed version of " + version + ".")
+}
+
+/*
+ Return npm-cli.js( npx-cli.js ) path of global node.exe folder, empty is not npm command or version folder include npm
+
+ Param:
+ 	- path:    version folder
+ 	- command: npm npx
+*/
+func npmCLI(path, command string) string {
+	if command != util.NPM && command != "npx" {
+		return ""
+	}
+	if util.IsDirExist(path, command+".cmd") || util.IsDirExist(path, command) {
+		return ""
+	}
+	cli := filepath.Join(rootPath, "node_modules", util.NPM, "bin", command+"-cli.js")
+	if !util.IsDirExist(cli) {
+		return ""
+	}
+	return cli
+}
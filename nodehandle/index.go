@@ -0,0 +1,265 @@
+package nodehandle
+
+import (
+	// lib
+	"github.com/Kenshin/curl"
+	"github.com/bitly/go-simplejson"
+
+	// go
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	// local
+	"gnvm/util"
+)
+
+const (
+	NODETAB = "index.tab"
+)
+
+type (
+	/*
+	 Release of remote index, all index parser return it.
+	 Files is nil when index not provide files list, e.g. html directory listing.
+	 LTS is codename of LTS release, e.g. Hydrogen, empty is not LTS or unknown.
+	*/
+	Release struct {
+		Version string
+		Date    string
+		NPM     string
+		Files   []string
+		LTS     string
+	}
+
+	/*
+	 Index parser
+
+	 - Name:  parser name, e.g. json tab html
+	 - Sniff: return true when body is this parser format
+	 - Parse: parse body to Release collection, sort by newest
+	*/
+	IndexParser struct {
+		Name  string
+		Sniff func(body []byte) bool
+		Parse func(body []byte) ([]Release, error)
+	}
+)
+
+/*
+ Index parser collection, sniff by order, use AddParser add custom parser
+*/
+var parsers = []IndexParser{
+	{"json", sniffJSON, parseJSON},
+	{"tab", sniffTab, parseTab},
+	{"html", sniffHTML, parseHTML},
+}
+
+/*
+ Add custom index parser, custom parser sniff before built-in parser
+
+ Param:
+    - parser: IndexParser
+
+*/
+func AddParser(parser IndexParser) {
+	parsers = append([]IndexParser{parser}, parsers...)
+}
+
+/*
+ Get remote index body, when <url>/index.json not exist, fallback to <url>/index.tab and <url>/
+
+ Param:
+    - url: index.json url, e.g. http://npm.taobao.org/mirrors/node/index.json
+
+ Return:
+    - body
+    - error: include url and error of all attempts
+    - code: -1: get url error, -2: read res.body error
+
+*/
+func fetchIndex(url string) ([]byte, error, int) {
+	urls := []string{url}
+	if strings.HasSuffix(url, util.NODELIST) {
+		base := strings.TrimSuffix(url, util.NODELIST)
+		urls = append(urls, base+NODETAB, base)
+	}
+
+	// error of all attempts, credential of url is redacted
+	var errs []string
+	for _, v := range urls {
+		code, res, e := curl.Get(v)
+		if e != nil || code != 0 {
+			// curl.Get return response with error, e.g. status code is not 200
+			if res != nil && res.Body != nil {
+				res.Body.Close()
+			}
+			if e != nil {
+				errs = append(errs, util.Redact(v+" Error: "+e.Error()))
+			} else {
+				errs = append(errs, util.Redact(v)+" curl error code: "+strconv.Itoa(code))
+			}
+			continue
+		}
+		if res.StatusCode != 200 {
+			res.Body.Close()
+			errs = append(errs, util.Redact(v)+" response code: "+strconv.Itoa(res.StatusCode))
+			continue
+		}
+		body, e := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if e != nil {
+			return nil, e, -2
+		}
+		return body, nil, 0
+	}
+	return nil, errors.New(strings.Join(errs, "; ")), -1
+}
+
+/*
+ Parse index body usage content sniffing
+
+ Param:
+    - body: index.json, index.tab or html directory listing
+
+ Return:
+    - releases
+    - error
+    - code: -3: unknown index format, -4: parse index error
+
+*/
+func parseIndex(body []byte) ([]Release, error, int) {
+	for _, parser := range parsers {
+		if parser.Sniff(body) {
+			releases, err := parser.Parse(body)
+			if err != nil {
+				return nil, errors.New("parse " + parser.Name + " index error, " + err.Error()), -4
+			}
+			return releases, nil, 0
+		}
+	}
+	return nil, errors.New("unknown index format, support index.json, index.tab and html directory listing."), -3
+}
+
+func sniffJSON(body []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+}
+
+func parseJSON(body []byte) ([]Release, error) {
+	json, err := simplejson.NewJson(body)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := json.Array()
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []Release
+	for _, element := range arr {
+		if value, ok := element.(map[string]interface{}); ok {
+			release := Release{}
+			release.Version, _ = value["version"].(string)
+			release.Date, _ = value["date"].(string)
+			release.NPM, _ = value["npm"].(string)
+			// lts is false or codename
+			release.LTS, _ = value["lts"].(string)
+			if files, ok := value["files"].([]interface{}); ok {
+				release.Files = []string{}
+				for _, v := range files {
+					if file, ok := v.(string); ok {
+						release.Files = append(release.Files, file)
+					}
+				}
+			}
+			releases = append(releases, release)
+		}
+	}
+	return releases, nil
+}
+
+func sniffTab(body []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("version\t"))
+}
+
+/*
+ index.tab format, e.g.
+    version	date	files	npm	v8	uv	zlib	openssl	modules	lts	security
+    v5.9.0	2016-03-16	headers,win-x64-exe,...	3.7.3	4.6.85.31	1.8.0	1.2.8	1.0.2g	47	-	-
+*/
+func parseTab(body []byte) ([]Release, error) {
+	lines := strings.Split(strings.Replace(string(body), "\r", "", -1), "\n")
+	column := map[string]int{}
+	for idx, v := range strings.Split(lines[0], "\t") {
+		column[strings.TrimSpace(v)] = idx
+	}
+	if _, ok := column["version"]; !ok {
+		return nil, errors.New("not found version column.")
+	}
+
+	get := func(arr []string, name string) string {
+		if idx, ok := column[name]; ok && idx < len(arr) {
+			if value := strings.TrimSpace(arr[idx]); value != "-" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	var releases []Release
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		arr := strings.Split(line, "\t")
+		release := Release{Version: get(arr, "version"), Date: get(arr, "date"), NPM: get(arr, "npm"), LTS: get(arr, "lts")}
+
+		// lts is - or false when not LTS
+		if release.LTS == "-" || release.LTS == "false" {
+			release.LTS = ""
+		}
+		if _, ok := column["files"]; ok {
+			release.Files = []string{}
+			if files := get(arr, "files"); files != "" {
+				release.Files = strings.Split(files, ",")
+			}
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+func sniffHTML(body []byte) bool {
+	return bytes.Contains(bytes.ToLower(body), []byte("<a "))
+}
+
+/*
+ Html directory listing( autoindex ), only parse vX.Y.Z/ link, not include date, npm and files
+*/
+func parseHTML(body []byte) ([]Release, error) {
+	reg, _ := regexp.Compile(`(?i)href="(?:[^"]*/)?(v\d+\.\d+\.\d+)/"`)
+	exist, versions := map[string]bool{}, []string{}
+	for _, match := range reg.FindAllSubmatch(body, -1) {
+		ver := string(match[1])
+		if !exist[ver] {
+			exist[ver] = true
+			versions = append(versions, ver)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("not found any vX.Y.Z/ link.")
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return util.CompareNodeVer(versions[i][1:], versions[j][1:]) > 0
+	})
+
+	var releases []Release
+	for _, v := range versions {
+		releases = append(releases, Release{Version: v})
+	}
+	return releases, nil
+}
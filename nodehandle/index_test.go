@@ -0,0 +1,178 @@
+package nodehandle
+
+import (
+	// go
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	// local
+	"gnvm/util"
+)
+
+// same releases in all index formats, html directory listing only provide versions
+const (
+	fixtureJSON = `[
+  {"version":"v18.19.0","date":"2023-11-29","files":["win-x64-exe","win-x86-exe","linux-arm64"],"npm":"10.2.3","lts":"Hydrogen"},
+  {"version":"v5.9.0","date":"2016-03-16","files":["win-x64-exe"],"npm":"3.7.3","lts":false},
+  {"version":"v0.5.0","date":"2011-08-26","files":["src"],"lts":false}
+]`
+	fixtureTab = "version\tdate\tfiles\tnpm\tv8\tlts\r\n" +
+		"v18.19.0\t2023-11-29\twin-x64-exe,win-x86-exe,linux-arm64\t10.2.3\t10.2.154.26\tHydrogen\r\n" +
+		"v5.9.0\t2016-03-16\twin-x64-exe\t3.7.3\t4.6.85.31\t-\r\n" +
+		"v0.5.0\t2011-08-26\tsrc\t-\t3.1.8.25\t-\r\n"
+	fixtureHTML = `<html><head><title>Index of /dist/</title></head><body><pre>
+<a href="../">../</a>
+<a href="latest/">latest/</a>
+<a href="v0.5.0/">v0.5.0/</a>              26-Aug-2011 18:16    -
+<a href="/dist/v18.19.0/">v18.19.0/</a>    29-Nov-2023 13:31    -
+<a href="v5.9.0/">v5.9.0/</a>              16-Mar-2016 20:42    -
+<a href="v5.9.0/">v5.9.0/</a>              16-Mar-2016 20:42    -
+<a href="index.json">index.json</a>
+</pre></body></html>`
+)
+
+/*
+ Start index server, path not in files is 404
+
+ Return:
+ 	- server
+ 	- requested paths
+*/
+func indexServer(t *testing.T, files map[string]string) (*httptest.Server, *[]string) {
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if body, ok := files[r.URL.Path]; ok {
+			w.Write([]byte(body))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &paths
+}
+
+func TestSniffIndex(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"json", fixtureJSON, "json"},
+		{"json with space", "\n  " + fixtureJSON, "json"},
+		{"tab", fixtureTab, "tab"},
+		{"html", fixtureHTML, "html"},
+		{"html upper case", `<A HREF="v5.9.0/">v5.9.0/</A>`, "html"},
+		{"unknown", "v5.9.0\n", ""},
+		{"empty", "", ""},
+	}
+	for _, c := range cases {
+		name := ""
+		for _, parser := range parsers {
+			if parser.Sniff([]byte(c.body)) {
+				name = parser.Name
+				break
+			}
+		}
+		if name != c.want {
+			t.Errorf("%v: sniff = %q, want %q", c.name, name, c.want)
+		}
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	want := []Release{
+		{Version: "v18.19.0", Date: "2023-11-29", NPM: "10.2.3", Files: []string{"win-x64-exe", "win-x86-exe", "linux-arm64"}, LTS: "Hydrogen"},
+		{Version: "v5.9.0", Date: "2016-03-16", NPM: "3.7.3", Files: []string{"win-x64-exe"}},
+		{Version: "v0.5.0", Date: "2011-08-26", Files: []string{"src"}},
+	}
+	for name, body := range map[string]string{"json": fixtureJSON, "tab": fixtureTab} {
+		releases, err, code := parseIndex([]byte(body))
+		if err != nil || code != 0 {
+			t.Fatalf("%v: parseIndex error = %v, code = %v", name, err, code)
+		}
+		if !reflect.DeepEqual(releases, want) {
+			t.Errorf("%v: parseIndex = %+v, want %+v", name, releases, want)
+		}
+	}
+
+	releases, err := parseHTML([]byte(fixtureHTML))
+	if err != nil {
+		t.Fatalf("html: parseHTML error = %v", err)
+	}
+	if want := []Release{{Version: "v18.19.0"}, {Version: "v5.9.0"}, {Version: "v0.5.0"}}; !reflect.DeepEqual(releases, want) {
+		t.Errorf("html: parseHTML = %+v, want %+v", releases, want)
+	}
+
+	if _, err, code := parseIndex([]byte("version\nv5.9.0\n")); err == nil || code != -3 {
+		t.Errorf("unknown: parseIndex error = %v, code = %v, want code -3", err, code)
+	}
+	if _, err, code := parseIndex([]byte("version\tdate\n")); err != nil || code != 0 {
+		t.Errorf("tab header only: parseIndex error = %v, code = %v, want empty", err, code)
+	}
+	if _, err, code := parseIndex([]byte(`<a href="latest/">latest/</a>`)); err == nil || code != -4 {
+		t.Errorf("html without version: parseIndex error = %v, code = %v, want code -4", err, code)
+	}
+}
+
+func TestNewIndexFallback(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		paths []string
+	}{
+		{"json", map[string]string{"/dist/" + util.NODELIST: fixtureJSON, "/dist/" + NODETAB: fixtureTab}, []string{"/dist/" + util.NODELIST}},
+		{"tab", map[string]string{"/dist/" + NODETAB: fixtureTab, "/dist/": fixtureHTML}, []string{"/dist/" + util.NODELIST, "/dist/" + NODETAB}},
+		{"html", map[string]string{"/dist/": fixtureHTML}, []string{"/dist/" + util.NODELIST, "/dist/" + NODETAB, "/dist/"}},
+	}
+
+	nodists := map[string]*Nodist{}
+	for _, c := range cases {
+		server, paths := indexServer(t, c.files)
+		nodist, err, code := New(server.URL+"/dist/"+util.NODELIST, nil)
+		if err != nil || code != 0 {
+			t.Fatalf("%v: New error = %v, code = %v", c.name, err, code)
+		}
+		if !reflect.DeepEqual(*paths, c.paths) {
+			t.Errorf("%v: requested %v, want %v", c.name, *paths, c.paths)
+		}
+		nodists[c.name] = nodist
+	}
+
+	// json and tab provide all properties, html only provide versions
+	if !reflect.DeepEqual(nodists["json"], nodists["tab"]) {
+		t.Errorf("tab: Nodist = %+v, want %+v", nodists["tab"], nodists["json"])
+	}
+	if !reflect.DeepEqual(nodists["html"].Sorts, nodists["json"].Sorts) {
+		t.Errorf("html: Sorts = %v, want %v", nodists["html"].Sorts, nodists["json"].Sorts)
+	}
+	for ver, nd := range nodists["json"].nl {
+		if html, ok := nodists["html"].nl[ver]; !ok || html.ID != nd.ID || html.Node.Version != nd.Node.Version {
+			t.Errorf("html: %v = %+v, want ID %v", ver, html, nd.ID)
+		}
+	}
+}
+
+func TestFetchIndexError(t *testing.T) {
+	server, paths := indexServer(t, map[string]string{})
+	url := strings.Replace(server.URL, "://", "://alice:secret@", 1) + "/dist/"
+	_, err, code := fetchIndex(url + util.NODELIST)
+	if err == nil || code != -1 {
+		t.Fatalf("fetchIndex error = %v, code = %v, want code -1", err, code)
+	}
+	if len(*paths) != 3 {
+		t.Errorf("requested %v, want 3 attempts", *paths)
+	}
+	redacted := strings.Replace(url, "alice:secret@", "***@", 1)
+	for _, v := range []string{redacted + util.NODELIST, redacted + NODETAB, redacted + " "} {
+		if !strings.Contains(err.Error()+" ", v) {
+			t.Errorf("fetchIndex error = %q, want include %q", err.Error(), v)
+		}
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("fetchIndex error = %q, credential not redacted", err.Error())
+	}
+}
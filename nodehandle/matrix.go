@@ -0,0 +1,287 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	// local
+	"gnvm/config"
+	"gnvm/util"
+)
+
+const (
+	MATRIX_PASS  = "pass"
+	MATRIX_FAIL  = "fail"
+	MATRIX_ERROR = "error"
+)
+
+/*
+ Read versions of matrix file, e.g. .nvmrc-matrix, one or more versions per line, # is comment
+
+ 	# supported majors
+ 	14 16
+ 	18.19.0-x86
+ 	latest
+
+ Param:
+ 	- path: matrix file
+
+ Return:
+ 	- versions
+ 	- error
+*/
+func ReadMatrixFile(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, line := range strings.Split(string(data), "\n") {
+		versions = append(versions, strings.Fields(strings.SplitN(line, "#", 2)[0])...)
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("not found any version in " + path + ".")
+	}
+	return versions, nil
+}
+
+/*
+ Resolve matrix versions to installed version names, install missing versions, usage 'gnvm matrix'
+
+ Param:
+ 	- versions: x.xx.xx x.xx.xx-x86 latest and partial version, e.g. 18 18.19 18-x86
+
+ Return:
+ 	- version names, e.g. [14.21.3 16.20.2 18.19.0-x86]
+ 	- true( all resolved ) false( has error )
+*/
+func MatrixVersions(versions []string) ([]string, bool) {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm matrix' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	// find version of missing, partial version is remote newest version of it
+	missing := []string{}
+	for _, v := range versions {
+		if _, err := resolveVersion(v); err == nil {
+			continue
+		}
+		remote, err := remoteVersion(v)
+		if err != nil {
+			P(ERROR, "%v %v\n", v, err.Error())
+			return nil, false
+		}
+		P(NOTICE, "%v is not installed, install %v.\n", v, remote)
+		missing = append(missing, remote)
+	}
+	if len(missing) > 0 {
+		InstallNode(missing, false)
+	}
+
+	names, exist := []string{}, map[string]bool{}
+	for _, v := range versions {
+		name, err := resolveVersion(v)
+		if err != nil {
+			P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm help matrix")
+			return nil, false
+		}
+		if err := util.PolicyVersion(name); err != nil {
+			P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm policy")
+			return nil, false
+		}
+		if !exist[name] {
+			names, exist[name] = append(names, name), true
+		}
+	}
+	return names, true
+}
+
+/*
+ Return version of install, partial version is newest version of remote index
+
+ Param:
+ 	- version: x.xx.xx x.xx.xx-x86 latest 18 18.19 18-x86
+
+ Return:
+ 	- version, e.g. 18.19.0 18.19.0-x86 latest
+ 	- error
+*/
+func remoteVersion(version string) (string, error) {
+	version = util.EqualAbs("latest", version)
+	if ver := strings.Split(version, "-")[0]; version == util.LATEST || strings.Count(ver, ".") == 2 && util.VerifyNodeVer(ver) {
+		return version, nil
+	}
+	match := regexp.MustCompile(`^(\d+(?:\.\d+)?)(?:-(x86|x64))?$`).FindStringSubmatch(strings.ToLower(version))
+	if match == nil {
+		return "", errors.New("format error, e.g. 18.19.0 18.19.0-x86 18 latest.")
+	}
+
+	nodist, err := remoteIndex(config.GetConfig(config.REGISTRY))
+	if err != nil {
+		return "", err
+	}
+	newest := ""
+	for _, v := range nodist.Sorts {
+		if ok, _ := util.MatchRange(v[1:], match[1]); ok && (newest == "" || util.CompareNodeVer(v[1:], newest) > 0) {
+			newest = v[1:]
+		}
+	}
+	if newest == "" {
+		return "", errors.New("not found remote version of " + match[1] + ".")
+	}
+	if match[2] != "" && match[2] != util.DistArch(runtime.GOARCH) {
+		newest += "-" + match[2]
+	}
+	return newest, nil
+}
+
+/*
+ Run command under each version, output of command write to <home>\logs\matrix\<time>-<random>\<version>.log, usage 'gnvm matrix'
+
+ Param:
+ 	- names:    installed version names, from MatrixVersions
+ 	- args:     command and arguments, e.g. [npm test]
+ 	- parallel: number of versions run at the same time, 1 is sequential, 0 is all
+
+ Return:
+ 	- true( all pass ) false( any fail )
+*/
+func Matrix(names, args []string, parallel int) bool {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm matrix' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	// logs folder of this run, e.g. <home>\logs\matrix\20160316-120000-123456
+	root := filepath.Join(util.LogsPath, "matrix")
+	if err := os.MkdirAll(root, 0777); err != nil {
+		P(ERROR, "create %v folder Error: %v.\n", root, err.Error())
+		return false
+	}
+	logs, err := ioutil.TempDir(root, time.Now().Format("20060102-150405")+"-")
+	if err != nil {
+		P(ERROR, "create %v folder Error: %v.\n", root, err.Error())
+		return false
+	}
+	if parallel <= 0 || parallel > len(names) {
+		parallel = len(names)
+	}
+
+	list := &MatrixList{Command: strings.Join(args, " "), Logs: logs, Items: make([]MatrixItem, len(names))}
+	P(DEFAULT, "Run '%v' under Node.js versions [%v], logs in %v.\n", list.Command, strings.Join(names, ", "), logs)
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+		queue = make(chan struct{}, parallel)
+	)
+	for idx, name := range names {
+		wg.Add(1)
+		queue <- struct{}{}
+		go func(item *MatrixItem, name string) {
+			defer func() {
+				<-queue
+				wg.Done()
+			}()
+			item.Version, item.Log = name, filepath.Join(logs, name+".log")
+			matrixRun(item, args)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if item.Result == MATRIX_PASS {
+				P(DEFAULT, "%v %v %v\n", CP{Green, false, None, false, "pass"}, item.Version, item.Duration)
+			} else {
+				P(DEFAULT, "%v %v %v %v\n", CP{Red, false, None, false, item.Result}, item.Version, item.Duration, item.Error)
+			}
+		}(&list.Items[idx], name)
+	}
+	wg.Wait()
+
+	ok := true
+	for _, item := range list.Items {
+		if item.Result != MATRIX_PASS {
+			ok = false
+		}
+	}
+
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm matrix", err.Error())
+		}
+		return ok
+	}
+
+	fmt.Printf("\n%-16v %-6v %-5v %-10v %v\n", "VERSION", "RESULT", "CODE", "DURATION", "LOG")
+	for _, item := range list.Items {
+		fmt.Printf("%-16v %-6v %-5v %-10v %v\n", item.Version, item.Result, item.Code, item.Duration, item.Log)
+	}
+	if ok {
+		P(DEFAULT, "Matrix success, all %v versions passed.\n", len(list.Items))
+	} else {
+		P(ERROR, "Matrix fail, please check logs in %v.\n", logs)
+	}
+	return ok
+}
+
+/*
+ Run command of matrix item, stdout and stderr write to item log
+
+ Param:
+ 	- item: set result, code, duration and error
+ 	- args: command and arguments
+*/
+func matrixRun(item *MatrixItem, args []string) {
+	start := time.Now()
+	defer func() {
+		item.Duration = time.Since(start).Round(time.Millisecond).String()
+	}()
+
+	file, err := os.Create(item.Log)
+	if err != nil {
+		item.Result, item.Code, item.Error = MATRIX_ERROR, -1, err.Error()
+		return
+	}
+	defer file.Close()
+	fmt.Fprintf(file, "> gnvm exec %v -- %v\n\n", item.Version, strings.Join(args, " "))
+
+	cmd, err := execCommand(item.Version, args)
+	if err != nil {
+		item.Result, item.Code, item.Error = MATRIX_ERROR, -1, err.Error()
+		fmt.Fprintln(file, err.Error())
+		return
+	}
+	cmd.Stdout, cmd.Stderr = file, file
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			item.Result, item.Code, item.Error = MATRIX_FAIL, exitErr.ExitCode(), "exit code "+fmt.Sprint(exitErr.ExitCode())
+		} else {
+			item.Result, item.Code, item.Error = MATRIX_ERROR, -1, err.Error()
+		}
+		fmt.Fprintf(file, "\n%v\n", err.Error())
+		return
+	}
+	item.Result = MATRIX_PASS
+}
@@ -0,0 +1,188 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	// local
+	"gnvm/config"
+	"gnvm/util"
+)
+
+const (
+	MIGRATE_PLAN  = "plan"
+	MIGRATE_OK    = "ok"
+	MIGRATE_SKIP  = "skip"
+	MIGRATE_ERROR = "error"
+
+	MIGRATE_VERSION = "version"
+	MIGRATE_CONFIG  = "config"
+	MIGRATE_SESSION = "session"
+)
+
+/*
+ Move version folders of legacy flat layout to <home>/versions/<flavor>/<version>-<arch>, usage 'gnvm migrate'
+ idempotent, target folder exist is skip
+
+ Param:
+ 	- from:   legacy root folder, empty is discovery
+ 	- dryRun: true( only print plan, not move and not write config )
+
+ Return:
+ 	- true( success ) false( has error )
+*/
+func Migrate(from string, dryRun bool) bool {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm migrate' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	list := &MigrateList{DryRun: dryRun, Items: flatFolders(from)}
+
+	// move version folders
+	for idx := range list.Items {
+		item := &list.Items[idx]
+
+		// legacy layout not include flavor, io.js is which index listed the version, index unavailable is node
+		if flavor, _, _, _ := findRelease(config.GetConfig(config.REGISTRY), item.Name); flavor == util.FLAVOR_IOJS {
+			item.To = util.FlavorPath(flavor, item.Name)
+		}
+
+		switch {
+		case util.IsDirExist(item.To):
+			item.Status, item.Message = MIGRATE_SKIP, "target folder exist, please remove one of them."
+		case dryRun:
+			item.Status = MIGRATE_PLAN
+		default:
+			if err := os.MkdirAll(filepath.Dir(item.To), 0777); err != nil {
+				item.Status, item.Message = MIGRATE_ERROR, err.Error()
+			} else if err := util.Move(item.From, item.To); err != nil {
+				item.Status, item.Message = MIGRATE_ERROR, err.Error()
+			} else {
+				item.Status = MIGRATE_OK
+			}
+		}
+	}
+
+	// config references, e.g. globalversion 5.10.1-x64 -> 5.10.1
+	for _, key := range []string{config.GLOBAL_VERSION, config.LATEST_VERSION} {
+		value := config.GetConfig(key)
+		name := util.CanonicalName(value)
+		if name == value {
+			continue
+		}
+		item := MigrateItem{Kind: MIGRATE_CONFIG, Name: key, From: value, To: name, Status: MIGRATE_PLAN}
+		if !dryRun {
+			if config.SetConfig(key, name) == "" {
+				item.Status, item.Message = MIGRATE_ERROR, "write config fail."
+			} else {
+				item.Status = MIGRATE_OK
+			}
+		}
+		list.Items = append(list.Items, item)
+	}
+
+	// session script of old layout
+	if content := gnsContent(); util.IsDirExist(GNS_HOME) {
+		if data, err := ioutil.ReadFile(GNS_HOME); err == nil && string(data) != content {
+			item := MigrateItem{Kind: MIGRATE_SESSION, Name: GNS, From: GNS_HOME, To: GNS_HOME, Status: MIGRATE_PLAN}
+			if !dryRun {
+				if err := util.WriteFile(GNS_HOME, []byte(content), 0777); err != nil {
+					item.Status, item.Message = MIGRATE_ERROR, err.Error()
+				} else {
+					item.Status = MIGRATE_OK
+				}
+			}
+			list.Items = append(list.Items, item)
+		}
+	}
+
+	ok := true
+	for _, item := range list.Items {
+		if item.Status == MIGRATE_ERROR {
+			ok = false
+		}
+	}
+
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm migrate", err.Error())
+		}
+		return ok
+	}
+
+	if len(list.Items) == 0 {
+		P(DEFAULT, "layout of %v is up to date, not any version need to migrate.\n", util.VersionsPath)
+		return ok
+	}
+	for _, item := range list.Items {
+		switch item.Status {
+		case MIGRATE_OK:
+			P(DEFAULT, "%v %v %v %v -> %v\n", CP{Green, false, None, false, "ok   "}, item.Kind, item.Name, item.From, item.To)
+		case MIGRATE_ERROR:
+			P(DEFAULT, "%v %v %v %v -> %v, Error: %v\n", CP{Red, false, None, false, "error"}, item.Kind, item.Name, item.From, item.To, item.Message)
+		case MIGRATE_SKIP:
+			P(DEFAULT, "%v %v %v %v -> %v, %v\n", "skip ", item.Kind, item.Name, item.From, item.To, item.Message)
+		default:
+			P(DEFAULT, "%v %v %v %v -> %v\n", "plan ", item.Kind, item.Name, item.From, item.To)
+		}
+	}
+	if dryRun {
+		P(NOTICE, "dry run, not any change. please use '%v' apply it.\n", "gnvm migrate")
+	} else if !ok {
+		P(WARING, "migrate has errors, please check and re-run '%v'.\n", "gnvm migrate")
+	} else {
+		P(DEFAULT, "Migrate success, versions folder is %v.\n", util.VersionsPath)
+	}
+	return ok
+}
+
+/*
+ Return version folders of legacy flat layout, include <root>/x.xx.xx and <GNVM_HOME>/versions/x.xx.xx
+ when GNVM_HOME is set, legacy global node.exe folder is also scanned
+
+ Param:
+ 	- from: legacy root folder, empty is discovery
+
+ Return:
+ 	- migrate item collection, Status is empty
+*/
+func flatFolders(from string) []MigrateItem {
+	roots := []string{util.GlobalNodePath, filepath.Clean(rootPath), util.VersionsPath}
+	if from != "" {
+		roots = append([]string{filepath.Clean(from)}, roots...)
+	} else if util.GnvmHome != "" {
+		roots = append([]string{filepath.Clean(util.LegacyNodePath())}, roots...)
+	}
+
+	items := []MigrateItem{}
+	exclude := map[string]bool{}
+	for _, root := range roots {
+		if exclude[root] {
+			continue
+		}
+		exclude[root] = true
+		files, err := ioutil.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			ver, arch, _, err := util.ParseNodeVer(file.Name())
+			if err != nil || ver == util.LATEST || !file.IsDir() || !util.IsDirExist(root, file.Name(), util.NODE) {
+				continue
+			}
+			name := util.VersionName(ver, util.DistArch(arch))
+			items = append(items, MigrateItem{Kind: MIGRATE_VERSION, Name: name, From: filepath.Join(root, file.Name()), To: util.VersionPath(name)})
+		}
+	}
+	return items
+}
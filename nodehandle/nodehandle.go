@@ -8,12 +8,12 @@ import (
 
 	// go
 	//"log"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
 
 	// local
@@ -21,21 +21,51 @@ import (
 	"gnvm/util"
 )
 
-var rootPath, latURL string
+// global node.exe folder, config noderoot when GNVM_HOME is set
+var rootPath string
+
+// index.json cache, key is registry url
+var indexes = make(map[string]*Nodist)
 
 func init() {
 	rootPath = util.GlobalNodePath + util.DIVIDE
-	latURL = config.GetConfig(config.REGISTRY) + util.LATEST + "/" + util.SHASUMS
+	if util.GnvmHome != "" {
+		rootPath = config.GetConfig(config.NODEROOT) + util.DIVIDE
+	}
+	GNS_HOME = rootPath + GNS
+}
+
+/*
+ Return remote latest SHASUMS256.txt url of registry, e.g. http://nodejs.org/dist/latest/SHASUMS256.txt
+*/
+func latURL() string {
+	return config.GetConfig(config.REGISTRY) + util.LATEST + "/" + util.SHASUMS
+}
+
+/*
+ Return <root>/node.exe version name, e.g. x.xx.xx-x86 ( only rumtime.GOARCH == "amd64" )
+*/
+func globalVersion() (string, error) {
+	global, err := util.GetNodeVer(rootPath)
+	if err != nil {
+		return "", err
+	}
+	if bit, err := util.Arch(rootPath); err == nil {
+		if bit == "x86" && runtime.GOARCH == "amd64" {
+			global += "-" + bit
+		}
+	}
+	return global, nil
 }
 
 /**
  * rootPath    : node.exe global path,         e.g. x:\xxx\xx\xx\
  *
  * global      : global node.exe version num,  e.g. x.xx.xx-x86 ( only rumtime.GOARCH == "amd64", suffix include: 'x86' and 'x64' )
- * globalPath  : global node.exe version path, e.g. x:\xxx\xx\xx\x.xx.xx-x86
+ * globalPath  : global node.exe version path, e.g. <home>\versions\node\x.xx.xx-x86\
  *
  * newer       : newer node.exe version num,   e.g. x.xx.xx
- * newerPath   : newer node.exe version path,  e.g. <rootPath>\x.xx.xx\
+ * newerPath   : newer node.exe version path,  e.g. <home>\versions\node\x.xx.xx-x64\
  *
  */
 func Use(newer string) bool {
@@ -56,23 +86,23 @@ func Use(newer string) bool {
 		return false
 	}
 
+	// verify newer is allowed by policy
+	if err := util.PolicyVersion(newer); err != nil {
+		P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm policy")
+		return false
+	}
+
 	// set newerPath and verify newerPath is exist?
-	newerPath := rootPath + newer
+	newerPath := util.VersionPath(newer) + util.DIVIDE
 	if _, err := util.GetNodeVer(newerPath); err != nil {
 		P(WARING, "%v folder is not exist %v, use '%v' get local Node.js version list. See '%v'.\n", newer, "node.exe", "gnvm ls", "gnvm help ls")
 		return false
 	}
 
 	// get <root>/node.exe version, when exist, get full version, e.g. x.xx.xx-x86
-	global, err := util.GetNodeVer(rootPath)
+	global, err := globalVersion()
 	if err != nil {
 		P(WARING, "not found %v Node.js version.\n", "global")
-	} else {
-		if bit, err := util.Arch(rootPath); err == nil {
-			if bit == "x86" && runtime.GOARCH == "amd64" {
-				global += "-" + bit
-			}
-		}
 	}
 
 	// check newer is global
@@ -81,19 +111,13 @@ func Use(newer string) bool {
 		return false
 	}
 
-	// set globalPath
-	globalPath := rootPath + global
-
-	// <root>/global is exist? when not exist, create global folder
-	if !util.IsDirExist(globalPath) {
-		if err := os.Mkdir(globalPath, 0777); err != nil {
+	// backup copy <root>/node.exe to <home>/versions/<flavor>/<global>/node.exe, when not exist, create global folder
+	if global != "" {
+		globalPath := util.VersionPath(global) + util.DIVIDE
+		if err := os.MkdirAll(globalPath, 0777); err != nil {
 			P(ERROR, "create %v folder Error: %v.\n", global, err.Error())
 			return false
 		}
-	}
-
-	// backup copy <root>/node.exe to <root>/global/node.exe
-	if global != "" {
 		if err := util.Copy(rootPath, globalPath, util.NODE); err != nil {
 			P(ERROR, "copy %v to %v folder Error: %v.\n", rootPath, globalPath, err.Error())
 			return false
@@ -119,13 +143,19 @@ func Use(newer string) bool {
  	- global: when global == true, call Use func.
 
  Return:
- 	- code: dl[0].Code, usage 'gnvm update latest'
+ 	- code: dl[0].Code, usage 'gnvm update latest', INSTALL_PACKAGES_FAIL is node.exe installed but default packages fail
 
 */
 func InstallNode(args []string, global bool) int {
 
 	localVersion, isLatest, code, dl, ts := "", false, 0, new(curl.Download), new(curl.Task)
 
+	// SHASUMS256.txt url and node.exe name of version, verify when policy require
+	checksums := map[string][]string{}
+
+	// installed versions and default packages result, usage --output
+	list := &InstallList{Items: []InstallItem{}}
+
 	// try catch
 	defer func() {
 		if err := recover(); err != nil {
@@ -139,11 +169,9 @@ func InstallNode(args []string, global bool) int {
 	}()
 
 	for _, v := range args {
-		ver, io, arch, suffix, err := util.ParseNodeVer(v)
+		ver, arch, suffix, err := util.ParseNodeVer(v)
 		if err != nil {
 			switch err.Error() {
-			case "1":
-				P(ERROR, "%v not node.exe download.\n", v)
 			case "2":
 				P(ERROR, "%v format error, suffix only must be '%v' or '%v'.\n", v, "x86", "x64")
 			case "3":
@@ -168,7 +196,7 @@ func InstallNode(args []string, global bool) int {
 			localVersion = config.GetConfig(config.LATEST_VERSION)
 			P(NOTICE, "local  latest version is %v.\n", localVersion)
 
-			version := util.GetLatVer(latURL)
+			version := util.GetLatVer(latURL())
 			if version == "" {
 				P(ERROR, "get latest version error, please check. See '%v'.\n", "gnvm config help")
 				break
@@ -181,27 +209,60 @@ func InstallNode(args []string, global bool) int {
 			isLatest = false
 		}
 
-		// ture folder name
+		// verify version is allowed by policy
+		if err := util.PolicyVersion(ver); err != nil {
+			P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm policy")
+			continue
+		}
+
+		// ture version name, e.g. 5.10.1-x86
 		if suffix != "" {
-			ver += "-" + suffix
+			ver = util.VersionName(ver, suffix)
 		}
 
-		// verify <root>/folder is exist
-		folder := rootPath + ver
-		if _, err := util.GetNodeVer(folder); err == nil {
+		// verify <home>/versions/<flavor>/<version>-<arch> is exist
+		if _, err := util.GetNodeVer(util.VersionPath(ver)); err == nil {
 			P(WARING, "%v folder exist.\n", ver)
 			continue
 		}
 
-		// get and set url( include iojs)
-		url := config.GetConfig(config.REGISTRY)
-		if io {
-			url = config.GetIOURL(url)
+		// verify registry is allowed by policy
+		registry := config.GetConfig(config.REGISTRY)
+		if err := util.PolicyRegistry(registry); err != nil {
+			P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm policy")
+			continue
+		}
+
+		// get release from index, flavor( include iojs ) and url are which index listed the version
+		semver := strings.Split(ver, "-")[0]
+		flavor, url, nd, err := findRelease(registry, ver)
+		if err != nil {
+			P(ERROR, "%v\n", err.Error())
+			continue
+		} else if nd == nil {
+			P(ERROR, "not found Node.js version %v from %v. See '%v'.\n", semver, util.Redact(registry+util.NODELIST), "gnvm search "+semver)
+			continue
+		}
+
+		// url template only usage registry
+		tmpl := config.GetConfig(config.URL_TEMPLATE)
+		if flavor == util.FLAVOR_IOJS {
+			tmpl = ""
+		}
+
+		folder := util.FlavorPath(flavor, ver)
+		if err := os.MkdirAll(filepath.Dir(folder), 0777); err != nil {
+			P(ERROR, "create %v folder Error: %v.\n", filepath.Dir(folder), err.Error())
+			continue
 		}
 
-		// add task
-		if url, err := util.GetRemoteNodePath(url, ver, arch); err == nil {
-			dl.AddTask(ts.New(url, ver, util.NODE, folder))
+		// add task, verify release files before download
+		if remote, err := util.GetRemoteNodePath(url, tmpl, flavor, ver, arch, nd.Files); err == nil {
+			dl.AddTask(ts.New(remote, ver, util.NODE, folder))
+			sums, name := util.ParseDistFile(util.ExeEntry(arch)).Checksums(tmpl, url, flavor, semver)
+			checksums[ver] = []string{sums, name}
+		} else {
+			P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm search "+semver)
 		}
 	}
 
@@ -212,7 +273,26 @@ func InstallNode(args []string, global bool) int {
 		P(DEFAULT, "Start download Node.js versions [%v].\n", strings.Join(arr, ", "))
 		newDL, errs := curl.New(*dl)
 		for _, task := range newDL {
-			v := strings.Replace(task.Dst, rootPath, "", -1)
+			v := task.Title
+			if util.PolicyVerify() {
+				if err := util.VerifyChecksum(checksums[v][0], checksums[v][1], filepath.Join(util.VersionPath(v), util.NODE)); err != nil {
+					os.RemoveAll(util.VersionPath(v))
+					P(ERROR, "verify %v Error: %v, removed. See '%v'.\n", v, err.Error(), "gnvm policy")
+					code = -1
+					continue
+				}
+				P(DEFAULT, "Verify %v %v success from %v.\n", v, checksums[v][1], util.SHASUMS)
+			}
+
+			// install default global packages to new version
+			item := InstallItem{Version: v, Path: util.VersionPath(v), Result: PACKAGE_OK}
+			if item.Packages = installDefaultPackages(v); !packagesOK(item.Packages) {
+				item.Result, item.Message = PACKAGE_FAIL, "some default packages not installed"
+				if code == 0 {
+					code = INSTALL_PACKAGES_FAIL
+				}
+			}
+			list.Items = append(list.Items, item)
 			if v != localVersion && isLatest {
 				config.SetConfig(config.LATEST_VERSION, v)
 				P(DEFAULT, "Set success, %v new value is %v\n", config.LATEST_VERSION, v)
@@ -224,18 +304,86 @@ func InstallNode(args []string, global bool) int {
 			}
 		}
 		if len(errs) > 0 {
-			code = (*dl)[0].Code
+			if code == 0 {
+				code = (*dl)[0].Code
+			}
 			s := ""
 			for _, v := range errs {
 				s += v.Error()
 			}
-			P(WARING, s)
+			P(WARING, "%v\n", util.Redact(s))
+		}
+	}
+
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm install", err.Error())
 		}
 	}
 
 	return code
 }
 
+/*
+ Get index.json of registry, cached by url
+
+ Param:
+ 	- url: registry url, e.g. http://npm.taobao.org/mirrors/node/
+
+ Return:
+ 	- nodist
+ 	- error: include redacted index url
+
+*/
+func remoteIndex(url string) (*Nodist, error) {
+	nodist, ok := indexes[url]
+	if !ok {
+		var err error
+		if nodist, err, _ = New(url+util.NODELIST, nil); err != nil {
+			return nil, errors.New("get " + util.Redact(url+util.NODELIST) + " Error: " + util.Redact(err.Error()))
+		}
+		indexes[url] = nodist
+	}
+	return nodist, nil
+}
+
+/*
+ Find release of version from index, Node.js index first, then io.js index
+ flavor is which index listed the version, io.js index only when registry provide io.js mirror and permitted by policy
+
+ Param:
+ 	- registry: Node.js registry url, e.g. http://nodejs.org/dist/
+ 	- version:  version name, e.g. 5.9.0 1.0.0-x86
+
+ Return:
+ 	- flavor:   FLAVOR_NODE or FLAVOR_IOJS, empty is not listed
+ 	- url:      registry url of the index listed the version
+ 	- nd:       release of the version, nil is not listed
+ 	- error:    get index error
+
+*/
+func findRelease(registry, version string) (string, string, *NodeDetail, error) {
+	version = strings.Split(version, "-")[0]
+	urls := []string{registry}
+	if ioURL := config.GetConfig(config.IOJS_REGISTRY); ioURL != registry && util.PolicyRegistry(ioURL) == nil {
+		urls = append(urls, ioURL)
+	}
+	for idx, url := range urls {
+		nodist, err := remoteIndex(url)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if nd, ok := nodist.nl["v"+version]; ok {
+			flavor := util.FLAVOR_NODE
+			if idx == 1 {
+				flavor = util.FLAVOR_IOJS
+			}
+			return flavor, url, &nd, nil
+		}
+	}
+	return "", "", nil, nil
+}
+
 /*
  Uninstall node and npm
 
@@ -255,20 +403,20 @@ func Uninstall(folder string) {
 	}()
 
 	// set removePath
-	removePath := rootPath + folder
+	removePath := util.VersionPath(folder)
 
 	if folder == util.UNKNOWN {
 		P(ERROR, "current latest version is %v, please usage '%v' first. See '%v'.\n", folder, "gnvm update latest", "gnvm help update")
 		return
 	}
 
-	// rootPath/version is exist
+	// <home>/versions/<flavor>/<version>-<arch> is exist
 	if util.IsDirExist(removePath) != true {
 		P(ERROR, "%v folder is not exist. See '%v'.\n", folder, "gnvm ls")
 		return
 	}
 
-	// remove rootPath/version folder
+	// remove <home>/versions/<flavor>/<version>-<arch> folder
 	if err := os.RemoveAll(removePath); err != nil {
 		P(ERROR, "uninstall %v fail, Error: %v.\n", folder, err.Error())
 		return
@@ -298,25 +446,25 @@ func Update(global bool) {
 		}
 	}()
 
-	localVersion, remoteVersion := config.GetConfig(config.LATEST_VERSION), util.GetLatVer(latURL)
+	localVersion, remoteVersion := config.GetConfig(config.LATEST_VERSION), util.GetLatVer(latURL())
 
 	P(NOTICE, "local  Node.js latest version is %v.\n", localVersion)
 	if remoteVersion == "" {
 		P(ERROR, "get latest version error, please check. See '%v'.\n", "gnvm help config")
 		return
 	}
-	P(NOTICE, "remote Node.js latest version is %v from %v.\n", remoteVersion, config.GetConfig("registry"))
+	P(NOTICE, "remote Node.js latest version is %v from %v.\n", remoteVersion, util.Redact(config.GetConfig("registry")))
 
 	local, remote, args := util.FormatNodeVer(localVersion), util.FormatNodeVer(remoteVersion), []string{remoteVersion}
 
 	switch {
 	case localVersion == util.UNKNOWN:
-		if code := InstallNode(args, global); code == 0 {
+		if code := InstallNode(args, global); code == 0 || code == INSTALL_PACKAGES_FAIL {
 			config.SetConfig(config.LATEST_VERSION, remoteVersion)
 			P(DEFAULT, "Update Node.js latest success, current latest version is %v.\n", remoteVersion)
 		}
 	case local == remote:
-		if util.IsDirExist(rootPath + localVersion) {
+		if util.IsDirExist(util.VersionPath(localVersion)) {
 			cp := CP{Red, false, None, false, "="}
 			P(DEFAULT, "Remote latest version %v %v latest version %v, don't need to upgrade.\n", remoteVersion, cp, localVersion)
 			if global {
@@ -326,7 +474,7 @@ func Update(global bool) {
 			}
 		} else {
 			P(WARING, "%v folder is not exist. See '%v'.\n", localVersion, "gnvm ls")
-			if code := InstallNode(args, global); code == 0 {
+			if code := InstallNode(args, global); code == 0 || code == INSTALL_PACKAGES_FAIL {
 				P(DEFAULT, "Local Node.js latest version is %v.\n", localVersion)
 			}
 		}
@@ -336,7 +484,7 @@ func Update(global bool) {
 	case local < remote:
 		cp := CP{Red, false, None, false, ">"}
 		P(WARING, "remote latest version %v %v local latest version %v.\n", remoteVersion, cp, localVersion)
-		if code := InstallNode(args, global); code == 0 {
+		if code := InstallNode(args, global); code == 0 || code == INSTALL_PACKAGES_FAIL {
 			config.SetConfig(config.LATEST_VERSION, remoteVersion)
 			P(DEFAULT, "Update success, Node.js latest version is %v.\n", remoteVersion)
 		}
@@ -351,20 +499,15 @@ func Update(global bool) {
 
 */
 func Search(s string) {
-	regex, err := util.FormatWildcard(s, latURL)
+	regex, err := util.FormatWildcard(s, latURL())
 	if err != nil {
 		P(ERROR, "%v not an %v Node.js version.\n", s, "valid")
 		return
 	}
 
-	// set url
-	url := config.GetConfig(config.REGISTRY)
-	if arr := strings.Split(s, "."); len(arr) == 3 {
-		if ver, _ := strconv.Atoi(arr[0]); ver >= 1 && ver <= 3 {
-			url = config.GetIOURL(url)
-		}
-	}
-	url += util.NODELIST
+	// set url, Node.js index first
+	registry := config.GetConfig(config.REGISTRY)
+	url := registry + util.NODELIST
 
 	// try catch
 	defer func() {
@@ -376,23 +519,32 @@ func Search(s string) {
 	}()
 
 	// print
-	P(DEFAULT, "Search Node.js version rules [%v] from %v, please wait.\n", s, url)
+	P(DEFAULT, "Search Node.js version rules [%v] from %v, please wait.\n", s, util.Redact(url))
 
-	// generate nodist
+	// generate nodist, when Node.js index not listed any version of rules, io.js index
 	nodist, err, code := New(url, regex)
+	if ioURL := config.GetConfig(config.IOJS_REGISTRY); err == nil && len(nodist.nl) == 0 && ioURL != registry {
+		url = ioURL + util.NODELIST
+		P(DEFAULT, "not found from Node.js index, search io.js version rules [%v] from %v.\n", s, util.Redact(url))
+		nodist, err, code = New(url, regex)
+	}
 	if err != nil {
 		if code == -1 {
-			P(ERROR, "'%v' get url %v error, Error: %v\n", "gnvm search", url, err)
+			P(ERROR, "'%v' get url %v error, Error: %v\n", "gnvm search", util.Redact(url), util.Redact(fmt.Sprint(err)))
 		} else {
 			P(ERROR, "%v an error has occurred. please check. Error: %v\n", "gnvm search", err)
 		}
 		return
 	}
 
-	if len(nodist.nl) > 0 {
+	if util.IsMachine() {
+		if err := util.Render(nodist.Result(url, 0)); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm search", err.Error())
+		}
+	} else if len(nodist.nl) > 0 {
 		nodist.Detail(0)
 	} else {
-		P(WARING, "not search any Node.js version details, use rules [%v] from %v.\n", s, url)
+		P(WARING, "not search any Node.js version details, use rules [%v] from %v.\n", s, util.Redact(url))
 	}
 }
 
@@ -414,8 +566,8 @@ func LS(isPrint bool) ([]string, error) {
 	}()
 
 	var lsArr []string
-	existVersion := false
-	files, err := ioutil.ReadDir(rootPath)
+	existVersion, list := false, &LocalList{Root: util.VersionsPath, Versions: []LocalVersion{}}
+	versions, err := util.InstalledVersions()
 
 	// show error
 	if err != nil {
@@ -423,48 +575,39 @@ func LS(isPrint bool) ([]string, error) {
 		return lsArr, err
 	}
 
-	P(NOTICE, "gnvm.exe root is %v \n", rootPath)
-	for _, file := range files {
-		// set version
-		version := file.Name()
-
-		// check node version
-		if util.VerifyNodeVer(version) {
-
-			// <root>/x.xx.xx/node.exe is exist
-			if util.IsDirExist(rootPath + version + util.DIVIDE + util.NODE) {
-				desc := ""
-				switch {
-				case version == config.GetConfig(config.GLOBAL_VERSION) && version == config.GetConfig(config.LATEST_VERSION):
-					desc = " -- global, latest"
-				case version == config.GetConfig(config.LATEST_VERSION):
-					desc = " -- latest"
-				case version == config.GetConfig(config.GLOBAL_VERSION):
-					desc = " -- global"
-				}
-
-				ver, _, _, suffix, _ := util.ParseNodeVer(version)
-				if suffix == "x86" {
-					desc = " -- x86"
-				} else if suffix == "x64" {
-					desc = " -- x64"
-				}
+	P(NOTICE, "gnvm.exe root is %v \n", util.VersionsPath)
+	for _, version := range versions {
+		desc := ""
+		switch {
+		case version == config.GetConfig(config.GLOBAL_VERSION) && version == config.GetConfig(config.LATEST_VERSION):
+			desc = " -- global, latest"
+		case version == config.GetConfig(config.LATEST_VERSION):
+			desc = " -- latest"
+		case version == config.GetConfig(config.GLOBAL_VERSION):
+			desc = " -- global"
+		}
 
-				// set true
-				existVersion = true
+		ver, arch, suffix, _ := util.ParseNodeVer(version)
+		list.Versions = append(list.Versions, LocalVersion{version, ver, util.DistArch(arch), util.VersionFlavor(version), version == config.GetConfig(config.GLOBAL_VERSION), version == config.GetConfig(config.LATEST_VERSION)})
+		if suffix == "x86" {
+			desc = " -- x86"
+		} else if suffix == "x64" {
+			desc = " -- x64"
+		}
 
-				// set lsArr
-				lsArr = append(lsArr, version)
+		// set true
+		existVersion = true
 
-				if isPrint {
-					if desc == "" {
-						P(DEFAULT, "v"+ver+desc, "\n")
-					} else {
-						P(DEFAULT, "%v", "v"+ver+desc, "\n")
-					}
+		// set lsArr
+		lsArr = append(lsArr, version)
 
-				}
+		if isPrint && !util.IsMachine() {
+			if desc == "" {
+				P(DEFAULT, "v"+ver+desc, "\n")
+			} else {
+				P(DEFAULT, "%v", "v"+ver+desc, "\n")
 			}
+
 		}
 	}
 
@@ -473,6 +616,17 @@ func LS(isPrint bool) ([]string, error) {
 		P(WARING, "don't have any available Node.js version, please check your input. See '%v'.\n", "gnvm help install")
 	}
 
+	// version folders of legacy flat layout
+	if flat := flatFolders(""); len(flat) > 0 {
+		P(WARING, "found %v version folders in legacy layout, please use '%v'. See '%v'.\n", len(flat), "gnvm migrate", "gnvm help migrate")
+	}
+
+	if isPrint && util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm ls", err.Error())
+		}
+	}
+
 	return lsArr, err
 }
 
@@ -488,7 +642,7 @@ func LsRemote(limit int, io bool) {
 	// set url
 	url := config.GetConfig(config.REGISTRY)
 	if io {
-		url = config.GetIOURL(url)
+		url = config.GetConfig(config.IOJS_REGISTRY)
 	}
 	url += util.NODELIST
 
@@ -502,20 +656,27 @@ func LsRemote(limit int, io bool) {
 	}()
 
 	// print
-	P(DEFAULT, "Read all Node.js version list from %v, please wait.\n", url)
+	P(DEFAULT, "Read all Node.js version list from %v, please wait.\n", util.Redact(url))
 
 	// generate nodist
 	nodist, err, code := New(url, nil)
 	if err != nil {
 		if code == -1 {
-			P(ERROR, "'%v' get url %v error, Error: %v\n", "gnvm ls -r -d", url, err)
+			P(ERROR, "'%v' get url %v error, Error: %v\n", "gnvm ls -r -d", util.Redact(url), util.Redact(fmt.Sprint(err)))
 		} else {
 			P(ERROR, "%v an error has occurred. please check your input. Error: %v\n", "gnvm ls -r -d", err)
 		}
 		return
 	}
 
-	if limit != -1 {
+	if util.IsMachine() {
+		if limit == -1 {
+			limit = 0
+		}
+		if err := util.Render(nodist.Result(url, limit)); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm ls -r", err.Error())
+		}
+	} else if limit != -1 {
 		nodist.Detail(limit)
 	} else {
 		for _, v := range nodist.Sorts {
@@ -565,7 +726,7 @@ func NodeVersion(args []string) {
 					P(WARING, "latest version is %v, please use '%v'. See '%v'.\n", util.UNKNOWN, "gnvm node-version latest -r", "gnvm help node-version")
 				}
 			case args[0] == "latest" && remote:
-				remoteVersion := util.GetLatVer(latURL)
+				remoteVersion := util.GetLatVer(latURL())
 				if remoteVersion == "" {
 					P(ERROR, "get remote %v Node.js %v error, please check your input. See '%v'.\n", config.GetConfig(config.REGISTRY), "latest version", "gnvm help config")
 					return
@@ -589,6 +750,14 @@ func NodeVersion(args []string) {
 
 	isLatest, isGlobal := false, false
 	latest, global := config.GetConfig(config.LATEST_VERSION), config.GetConfig(config.GLOBAL_VERSION)
+	result := &NodeVersionResult{Registry: config.GetConfig(config.REGISTRY)}
+	if util.IsMachine() {
+		defer func() {
+			if err := util.Render(result); err != nil {
+				P(ERROR, "'%v' Error: %v\n", "gnvm node-version", err.Error())
+			}
+		}()
+	}
 	if len(args) == 0 {
 		isLatest = true
 		isGlobal = true
@@ -606,25 +775,30 @@ func NodeVersion(args []string) {
 			if global, err := util.GetNodeVer(rootPath); err == nil {
 				config.SetConfig(config.GLOBAL_VERSION, global)
 				P(DEFAULT, "Set success, %v new value is %v.\n", config.GLOBAL_VERSION, global)
+				result.Global = global
 			} else {
 				P(WARING, "global Node.js version is %v, please use %v or %v. See '%v'.\n", util.UNKNOWN, "gnvm install latest -g", "gnvm install x.xx.xx -g", "gnvm help install")
+				result.Global = util.UNKNOWN
 			}
 		} else {
 			P(DEFAULT, "Node.js %v version is %v.\n", "global", global)
+			result.Global = global
 		}
 	}
 
 	if isLatest {
+		result.Latest = latest
 		if latest == util.UNKNOWN {
 			P(WARING, "latest Node.js version is %v, please use %v or %v. See '%v'.\n", util.UNKNOWN, "gnvm install latest -g", "gnvm update latest", "gnvm help node-version")
 		} else {
 			P(DEFAULT, "Node.js %v version is %v.\n", "latest", latest)
 		}
-		remoteVersion := util.GetLatVer(latURL)
+		remoteVersion := util.GetLatVer(latURL())
 		if remoteVersion == "" {
 			P(ERROR, "get remote %v Node.js %v error, please check your input. See '%v'.\n", config.GetConfig(config.REGISTRY), "latest version", "gnvm help config")
 			return
 		}
+		result.RemoteLatest = remoteVersion
 		if latest == util.UNKNOWN {
 			P(NOTICE, "remote Node.js %v version is %v from %v.\n", "latest", remoteVersion, config.GetConfig(config.REGISTRY))
 			//config.SetConfig(config.LATEST_VERSION, remoteVersion)
@@ -662,6 +836,15 @@ func Version(remote, detail bool) {
 		arch = "64 bit"
 	}
 
+	result := &VersionResult{Version: localVersion, Arch: arch}
+	if util.IsMachine() {
+		defer func() {
+			if err := util.Render(result); err != nil {
+				P(ERROR, "'%v' Error: %v\n", "gnvm version", err.Error())
+			}
+		}()
+	}
+
 	cp := CP{Red, true, None, true, "Kenshin Wang"}
 	P(DEFAULT, "Current version %v %v.", localVersion, arch, "\n")
 	P(DEFAULT, "Copyright (C) 2014-2016 %v <kenshin@ksria.com>", cp, "\n")
@@ -672,7 +855,7 @@ func Version(remote, detail bool) {
 		return
 	}
 
-	code, res, err := curl.Get("http://ksria.com/gnvm/CHANGELOG.md")
+	code, res, err := curl.Get(config.GetConfig(config.UPDATE_URL) + "CHANGELOG.md")
 	if code != 0 {
 		panic(err)
 	}
@@ -688,14 +871,18 @@ func Version(remote, detail bool) {
 
 				latestVersion, msg := arr[0][1:], ""
 				localArr, latestArr := strings.Split(localVersion, "."), strings.Split(latestVersion, ".")
+				result.Latest, result.Published = latestVersion, arr[1]
 
 				switch {
 				case latestArr[0] > localArr[0]:
 					msg = "must be upgraded."
+					result.Upgrade = "must"
 				case latestArr[1] > localArr[1]:
 					msg = "suggest to upgrade."
+					result.Upgrade = "suggest"
 				case latestArr[2] > localArr[2]:
 					msg = "optional upgrade."
+					result.Upgrade = "optional"
 				}
 
 				if msg != "" {
@@ -705,7 +892,11 @@ func Version(remote, detail bool) {
 
 		}
 		if line > 2 && detail {
-			P(DEFAULT, content)
+			if util.IsMachine() {
+				result.Changelog = append(result.Changelog, content)
+			} else {
+				P(DEFAULT, content)
+			}
 		}
 
 		return false
@@ -2,14 +2,10 @@ package nodehandle
 
 import (
 
-	// lib
-	"github.com/Kenshin/curl"
-	"github.com/bitly/go-simplejson"
-
 	// go
 	"fmt"
-	"io/ioutil"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 
@@ -28,10 +24,12 @@ type (
 	}
 
 	NodeDetail struct {
-		ID   int
-		Date string
+		ID    int
+		Date  string
+		Files []string
 		Node
 		NPM
+		LTS string
 	}
 
 	Nodist struct {
@@ -45,6 +43,7 @@ type (
 
  Param:
     - url:    index.json url, e.g. http://npm.taobao.org/mirrors/node/index.json
+              when not exist, fallback to index.tab and html directory listing.
     - filter: regexp when regexp == nil, filter all NodeDetail
 
  Return:
@@ -55,51 +54,41 @@ type (
       Code:
         - -1: get url error
         - -2: read res.body error
-        - -3: create json error
-        - -4: parse json error
+        - -3: unknown index format
+        - -4: parse index error
 
 */
 func New(url string, filter *regexp.Regexp) (*Nodist, error, int) {
-	code, res, err := curl.Get(url)
+	body, err, code := fetchIndex(url)
 	if err != nil {
 		return nil, err, code
 	}
-	defer res.Body.Close()
-
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, err, -2
-	}
 
-	json, err := simplejson.NewJson(body)
-	if err != nil {
-		return nil, err, -3
-	}
-	arr, err := json.Array()
+	releases, err, code := parseIndex(body)
 	if err != nil {
-		return nil, err, -4
+		return nil, err, code
 	}
 
 	nodist, idx := new(Nodist), 0
 	nodist.nl = make(map[string]NodeDetail, 0)
-	for _, element := range arr {
-		if value, ok := element.(map[string]interface{}); ok {
-			ver, _ := value["version"].(string)
-			if filter != nil {
-				if ok := filter.MatchString(ver[1:]); !ok {
-					continue
-				}
-			}
-			date, _ := value["date"].(string)
-			npm, _ := value["npm"].(string)
-			if npm == "" {
-				npm = "[x]"
+	for _, release := range releases {
+		ver := release.Version
+		if !strings.HasPrefix(ver, "v") {
+			continue
+		}
+		if filter != nil {
+			if ok := filter.MatchString(ver[1:]); !ok {
+				continue
 			}
-			exe := formatExe(ver[1:])
-			nodist.Sorts = append(nodist.Sorts, ver)
-			nodist.nl[ver] = NodeDetail{idx, date, Node{ver, exe}, NPM{npm}}
-			idx++
 		}
+		npm := release.NPM
+		if npm == "" {
+			npm = "[x]"
+		}
+		exe := formatExe(release.Files)
+		nodist.Sorts = append(nodist.Sorts, ver)
+		nodist.nl[ver] = NodeDetail{idx, release.Date, release.Files, Node{ver, exe}, NPM{npm}, release.LTS}
+		idx++
 	}
 	return nodist, nil, 0
 }
@@ -170,22 +159,21 @@ func (this *Nodist) Detail(limit int) {
  Format exe
 
  Param:
- 	- version: Node.js version
+ 	- files:   index.json files of the version
 
  Return:
- 	- exec:    formatting string, e.g. '[x]'
+ 	- exec:    formatting string, e.g. 'x86 x64' '[x]', '[?]' is index not provide files
 
 */
-func formatExe(version string) (exec string) {
-	switch util.GetNodeVerLev(util.FormatNodeVer(version)) {
-	case 0:
-		exec = "[x]"
-	case 1:
-		exec = "x86"
-	default:
-		exec = "x86 x64"
+func formatExe(files []string) (exec string) {
+	if files == nil {
+		return "[?]"
+	}
+	arr := util.DistArchs(files, util.ParseDistFile(util.ExeEntry(runtime.GOARCH)).OS, "exe")
+	if len(arr) == 0 {
+		return "[x]"
 	}
-	return
+	return strings.Join(arr, " ")
 }
 
 /*
@@ -24,10 +24,7 @@ import (
 )
 
 const (
-	LATNPMURL  = "https://raw.githubusercontent.com/npm/npm/master/package.json"
-	NPMTAOBAO  = "http://npm.taobao.org/mirrors/npm/"
-	NPMDEFAULT = "https://github.com/npm/npm/releases/"
-	ZIP        = ".zip"
+	ZIP = ".zip"
 )
 
 /*
@@ -257,6 +254,12 @@ func InstallNPM(version string) {
 		return
 	}
 
+	// verify npm registry is permitted by policy
+	if err := util.PolicyRegistry(config.GetConfig(config.NPM_REGISTRY)); err != nil {
+		P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm policy")
+		return
+	}
+
 	prompt, local, newver := "n", getLocalNPMVer(), version
 
 	if version == util.GLOBAL {
@@ -265,6 +268,13 @@ func InstallNPM(version string) {
 		newver = getLatNPMVer()
 	}
 
+	if util.IsMachine() {
+		if err := util.Render(&NPMResult{local, newver}); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm npm", err.Error())
+		}
+		return
+	}
+
 	cp := CP{Red, false, None, false, newver}
 	P(NOTICE, "local    npm version is %v\n", local)
 	P(NOTICE, "remote   npm version is %v\n", cp)
@@ -303,15 +313,12 @@ func getNodeNpmVer() string {
 		panic(errors.New("not exist global node.exe. please usage 'gnvm install latest -g' frist."))
 	}
 
-	url := config.GetConfig(config.REGISTRY)
-	if level := util.GetNodeVerLev(util.FormatNodeVer(ver)); level == 3 {
-		url = config.GetIOURL(url)
-	}
-	url += util.NODELIST
-
-	nd, err := FindNodeDetailByVer(url, ver)
+	// Node.js or io.js index, which index listed the version
+	_, _, nd, err := findRelease(config.GetConfig(config.REGISTRY), ver)
 	if err != nil {
 		panic(err)
+	} else if nd == nil {
+		panic(errors.New("not found Node.js version " + ver + " from remote index."))
 	}
 	return nd.NPM.Version
 }
@@ -324,7 +331,7 @@ func getNodeNpmVer() string {
 
 */
 func getLatNPMVer() string {
-	_, res, err := curl.Get(LATNPMURL)
+	_, res, err := curl.Get(config.GetConfig(config.NPM_METADATA))
 	if err != nil {
 		panic(err)
 	}
@@ -360,6 +367,17 @@ func getLocalNPMVer() string {
 	return strings.TrimSpace(string(out[:]))
 }
 
+/*
+ Return npm zip url of config npmregistry, e.g. http://npm.taobao.org/mirrors/npm/v3.8.5.zip
+
+ Param:
+    - ver: npm version
+
+*/
+func npmURL(ver string) string {
+	return config.GetConfig(config.NPM_REGISTRY) + "v" + ver + ZIP
+}
+
 /*
  Download / unzip / install npm
 
@@ -368,11 +386,7 @@ func getLocalNPMVer() string {
 
 */
 func downloadNpm(ver string) {
-	version := "v" + ver + ZIP
-	url := NPMTAOBAO + version
-	if config.GetConfig(config.REGISTRY) != util.ORIGIN_TAOBAO {
-		url = NPMDEFAULT + version
-	}
+	version, url := "v"+ver+ZIP, npmURL(ver)
 
 	// create npm
 	npm.New().SetZip(version)
@@ -0,0 +1,390 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	// local
+	"gnvm/config"
+	"gnvm/util"
+)
+
+const (
+	PACKAGE_OK   = "ok"
+	PACKAGE_FAIL = "fail"
+	PACKAGE_SKIP = "skip"
+	PACKAGE_PLAN = "plan"
+)
+
+// code of InstallNode, node.exe installed but default packages fail
+const INSTALL_PACKAGES_FAIL = -2
+
+// packages bundled with Node.js, not reinstall
+var bundled = map[string]bool{util.NPM: true, "corepack": true}
+
+/*
+ Global package of npm prefix
+
+ - Name:    package name, e.g. typescript @vue/cli
+ - Version: installed version, e.g. 5.3.3
+*/
+type Package struct {
+	Name    string
+	Version string
+}
+
+/*
+ Return node.exe folder and global npm prefix of version, prefix is from 'npm prefix -g', usage 'gnvm migrate-packages'
+
+ - global:     global node.exe version, node.exe folder is global node.exe folder
+ - x.xx.xx 18: installed version, node.exe folder is version folder
+
+ Param:
+ 	- version: global latest x.xx.xx x.xx.xx-x86 and partial version, e.g. 18
+
+ Return:
+ 	- name:   installed version name, e.g. 18.19.0
+ 	- path:   node.exe folder, npm of it run by node.exe of it
+ 	- prefix: npm prefix, global packages in <prefix>\node_modules
+ 	- error
+*/
+func npmPrefix(version string) (string, string, string, error) {
+	name, path := "", ""
+	if util.EqualAbs("global", version) == util.GLOBAL {
+		global, err := globalVersion()
+		if err != nil || global == "" {
+			return "", "", "", errors.New("not found global " + util.NODE + " in " + rootPath + ".")
+		}
+		if !util.IsDirExist(util.VersionPath(global), util.NODE) {
+			return "", "", "", errors.New("global version " + global + " is not installed, please use 'gnvm install " + global + "' first.")
+		}
+		name, path = global, filepath.Clean(rootPath)
+	} else {
+		var err error
+		if name, err = resolveVersion(version); err != nil {
+			return "", "", "", err
+		}
+		path = util.VersionPath(name)
+	}
+
+	out, err := npmRun(path, "prefix", "-g")
+	if err != nil {
+		return "", "", "", errors.New("get npm prefix of " + name + " Error: " + err.Error())
+	}
+	prefix := strings.TrimSpace(out)
+	if prefix == "" {
+		return "", "", "", errors.New("get npm prefix of " + name + " Error: empty output.")
+	}
+	return name, path, filepath.Clean(prefix), nil
+}
+
+/*
+ Run npm of node.exe folder, e.g. npm prefix -g
+
+ Param:
+ 	- path: node.exe folder
+ 	- args: npm arguments
+
+ Return:
+ 	- stdout of npm
+ 	- error: include last line of npm stderr
+*/
+func npmRun(path string, args ...string) (string, error) {
+	cmd, err := nodeCommand(path, append([]string{util.NPM}, args...))
+	if err != nil {
+		return "", err
+	}
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		if msg := npmError(stderr.String()); msg != "" {
+			err = errors.New(err.Error() + ", " + msg)
+		}
+		return stdout.String(), err
+	}
+	return stdout.String(), nil
+}
+
+/*
+ Return first error line of npm stderr, e.g. npm ERR! 404 Not Found, last line is log path of npm
+
+ Param:
+ 	- stderr: npm stderr
+
+ Return:
+ 	- error line, last line when not found, empty is not any output
+*/
+func npmError(stderr string) string {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "npm ERR!") || strings.HasPrefix(line, "npm error") {
+			if !strings.Contains(line, "A complete log of this run") && !strings.HasSuffix(line, "npm ERR!") && !strings.HasSuffix(line, "npm error") {
+				return line
+			}
+		}
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+/*
+ Return global packages of node.exe folder by 'npm ls -g --depth=0 --json', bundled npm and corepack is not included
+
+ Param:
+ 	- path: node.exe folder
+
+ Return:
+ 	- packages, sort by name
+ 	- error
+*/
+func npmPackages(path string) ([]Package, error) {
+	out, err := npmRun(path, "ls", "-g", "--depth=0", "--json")
+
+	// npm ls exit code is not 0 when include invalid or extraneous package, json is still output
+	tree := struct {
+		Dependencies map[string]struct {
+			Version string
+		}
+	}{}
+	if e := json.Unmarshal([]byte(out), &tree); e != nil {
+		if err == nil {
+			err = e
+		}
+		return nil, err
+	}
+
+	packages := []Package{}
+	for name, dep := range tree.Dependencies {
+		if !bundled[name] {
+			packages = append(packages, Package{Name: name, Version: dep.Version})
+		}
+	}
+	sort.SliceStable(packages, func(i, j int) bool {
+		return packages[i].Name < packages[j].Name
+	})
+	return packages, nil
+}
+
+/*
+ Install package to global npm prefix of node.exe folder, one package one 'npm install -g'
+
+ Param:
+ 	- path: node.exe folder, node.exe and npm of it
+ 	- spec: package spec, e.g. typescript typescript@5.3.3
+
+ Return:
+ 	- error: include npm output
+*/
+func installPackage(path, spec string) error {
+	_, err := npmRun(path, "install", "-g", spec)
+	return err
+}
+
+/*
+ Reinstall global packages of version to other version, usage 'gnvm migrate-packages' and 'gnvm install --reinstall-packages-from'
+
+ Param:
+ 	- from:        source version, include: global latest x.xx.xx and partial version, e.g. 18
+ 	- to:          target version, same as from
+ 	- sameVersion: true is install same version of package, false is latest version
+ 	- dryRun:      only print plan
+
+ Return:
+ 	- true( success ) false( any package fail )
+*/
+func MigratePackages(from, to string, sameVersion, dryRun bool) bool {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm migrate-packages' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	fromName, fromPath, fromPrefix, err := npmPrefix(from)
+	if err != nil {
+		P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm ls")
+		return false
+	}
+	toName, toPath, toPrefix, err := npmPrefix(to)
+	if err != nil {
+		P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm ls")
+		return false
+	}
+	if samePath(fromPrefix, toPrefix) {
+		P(WARING, "%v and %v are same npm prefix %v, not need migrate.\n", from, to, fromPrefix)
+		return true
+	}
+	if err := util.PolicyVersion(toName); err != nil {
+		P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm policy")
+		return false
+	}
+
+	packages, err := npmPackages(fromPath)
+	if err != nil {
+		P(ERROR, "read global packages of %v Error: %v\n", fromPrefix, util.Redact(err.Error()))
+		return false
+	}
+
+	// exist packages of target are skipped
+	exist := map[string]bool{}
+	if installed, err := npmPackages(toPath); err == nil {
+		for _, pkg := range installed {
+			exist[pkg.Name] = true
+		}
+	}
+
+	list := &PackageList{From: fromName, To: toName, Prefix: toPrefix, Items: []PackageItem{}}
+	if !util.IsMachine() && len(packages) > 0 {
+		P(DEFAULT, "Migrate global packages from %v( %v ) to %v( %v ).\n", fromName, fromPrefix, toName, toPrefix)
+	}
+	ok := true
+	for _, pkg := range packages {
+		item := PackageItem{Name: pkg.Name, Version: pkg.Version, Spec: pkg.Name}
+		if sameVersion && pkg.Version != "" {
+			item.Spec += "@" + pkg.Version
+		}
+		switch {
+		case exist[pkg.Name]:
+			item.Result, item.Message = PACKAGE_SKIP, "exist in "+toName
+		case dryRun:
+			item.Result = PACKAGE_PLAN
+		default:
+			if err := installPackage(toPath, item.Spec); err != nil {
+				item.Result, item.Message, ok = PACKAGE_FAIL, util.Redact(err.Error()), false
+			} else {
+				item.Result = PACKAGE_OK
+			}
+		}
+		list.Items = append(list.Items, item)
+		if !util.IsMachine() {
+			printPackage(item)
+		}
+	}
+
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm migrate-packages", err.Error())
+		}
+		return ok
+	}
+
+	switch {
+	case len(packages) == 0:
+		P(DEFAULT, "Not any global package of %v in %v.\n", fromName, fromPrefix)
+	case dryRun:
+		P(NOTICE, "dry run, please run without %v to apply it.\n", "--dry-run")
+	case ok:
+		P(DEFAULT, "Migrate success, global packages of %v are installed to %v.\n", fromName, toName)
+	default:
+		P(ERROR, "Migrate fail, some packages not installed to %v, please check.\n", toName)
+	}
+	return ok
+}
+
+/*
+ Return default global packages, config defaultpackages and <home>\default-packages file, usage 'gnvm install' and 'gnvm update'
+
+ Return:
+ 	- package specs, e.g. [typescript pnpm@8]
+ 	- error: read or verify default-packages file error
+*/
+func DefaultPackages() ([]string, error) {
+	specs, exist := []string{}, map[string]bool{}
+	add := func(values []string) {
+		for _, v := range values {
+			if !exist[v] {
+				specs, exist[v] = append(specs, v), true
+			}
+		}
+	}
+	add(util.SplitPackages(config.GetConfig(config.DEFAULT_PACKAGES)))
+
+	path := filepath.Join(util.HomePath, util.DEFAULT_PACKAGES)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return specs, nil
+	} else if err != nil {
+		return specs, errors.New("read " + path + " Error: " + err.Error())
+	}
+	if err := util.VerifyPackages(string(data)); err != nil {
+		return specs, errors.New(path + " " + err.Error())
+	}
+	add(util.SplitPackages(string(data)))
+	return specs, nil
+}
+
+/*
+ Install default global packages to new installed version by npm of it, npm prefix is version folder by default
+
+ Param:
+ 	- name: new installed version name, e.g. 18.19.0 18.19.0-x86
+
+ Return:
+ 	- install result of each package, read default-packages file error is a fail item
+*/
+func installDefaultPackages(name string) []PackageItem {
+	items := []PackageItem{}
+	specs, err := DefaultPackages()
+	if err != nil {
+		P(ERROR, "%v See '%v'.\n", err.Error(), "gnvm help config")
+		return append(items, PackageItem{Name: util.DEFAULT_PACKAGES, Spec: util.DEFAULT_PACKAGES, Result: PACKAGE_FAIL, Message: err.Error()})
+	}
+	if len(specs) == 0 {
+		return items
+	}
+
+	if !util.IsMachine() {
+		P(DEFAULT, "Install default packages [%v] to %v.\n", strings.Join(specs, ", "), name)
+	}
+	for _, spec := range specs {
+		item := PackageItem{Name: spec, Spec: spec, Result: PACKAGE_OK}
+		if err := installPackage(util.VersionPath(name), spec); err != nil {
+			item.Result, item.Message = PACKAGE_FAIL, util.Redact(err.Error())
+		}
+		items = append(items, item)
+		if !util.IsMachine() {
+			printPackage(item)
+		}
+	}
+	if !packagesOK(items) {
+		P(WARING, "some default packages not installed to %v, please use '%v' retry.\n", name, "gnvm exec "+name+" -- npm install -g <package>")
+	}
+	return items
+}
+
+/*
+ Return true when not any package fail
+*/
+func packagesOK(items []PackageItem) bool {
+	for _, item := range items {
+		if item.Result == PACKAGE_FAIL {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ Print package install result
+*/
+func printPackage(item PackageItem) {
+	switch item.Result {
+	case PACKAGE_OK:
+		P(DEFAULT, "%v %v\n", CP{Green, false, None, false, "ok  "}, item.Spec)
+	case PACKAGE_FAIL:
+		P(DEFAULT, "%v %v %v\n", CP{Red, false, None, false, "fail"}, item.Spec, item.Message)
+	default:
+		P(DEFAULT, "%-4v %v %v\n", item.Result, item.Spec, item.Message)
+	}
+}
@@ -0,0 +1,56 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"strings"
+
+	// local
+	"gnvm/util"
+)
+
+/*
+ Print organisation policy, usage 'gnvm policy'
+
+ Return:
+ 	- true( valid or not any policy ) false( policy file invalid )
+*/
+func ShowPolicy() bool {
+	p, err := util.GetPolicy()
+	if err != nil {
+		P(ERROR, "%v\n", err.Error())
+		return false
+	}
+
+	result := &PolicyResult{Allow: []string{}, Block: []string{}, Registries: []string{}}
+	if p != nil {
+		result = &PolicyResult{p.Path, p.Allow, p.Block, p.Registries, p.Verify}
+	}
+
+	if util.IsMachine() {
+		if err := util.Render(result); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm policy", err.Error())
+		}
+		return true
+	}
+
+	if p == nil {
+		P(NOTICE, "not found any policy file, all Node.js versions and registries are allowed. See '%v'.\n", "gnvm help policy")
+		return true
+	}
+
+	all := func(values []string) string {
+		if len(values) == 0 {
+			return "all"
+		}
+		return strings.Join(values, ", ")
+	}
+	P(NOTICE, "policy file is %v\n", p.Path)
+	P(DEFAULT, "allow      %v\n", all(p.Allow))
+	P(DEFAULT, "block      %v\n", strings.Join(p.Block, ", "))
+	P(DEFAULT, "registries %v\n", all(p.Registries))
+	P(DEFAULT, "verify     %v\n", p.Verify)
+	return true
+}
@@ -0,0 +1,272 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// local
+	"gnvm/config"
+	"gnvm/util"
+)
+
+const (
+	PRUNE_KEEP   = "keep"
+	PRUNE_REMOVE = "remove"
+)
+
+/*
+ Retention rules of 'gnvm prune', version is kept when match any keep rule
+
+ - KeepPerMajor: keep newest n versions of each major and arch, 0 is not keep
+ - KeepLTS:      keep LTS versions, from remote index lts
+ - Keep:         keep versions or ranges, e.g. 16.20.2 18.*.* ">=20.0.0"
+ - OlderThan:    only remove version folder older than, e.g. 180d 4w 72h, empty is not limit
+ - DryRun:       only print plan
+*/
+type PruneRule struct {
+	KeepPerMajor int
+	KeepLTS      bool
+	Keep         []string
+	OlderThan    string
+	DryRun       bool
+}
+
+/*
+ Remove installed versions not match retention rules, global and latest versions always kept, usage 'gnvm prune'
+
+ Param:
+ 	- rule: retention rules
+
+ Return:
+ 	- true( success ) false( has error )
+*/
+func Prune(rule PruneRule) bool {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm prune' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	if rule.KeepPerMajor <= 0 && !rule.KeepLTS && len(rule.Keep) == 0 && rule.OlderThan == "" {
+		P(ERROR, "%v need at least one rule of %v, please check your input. See '%v'.\n", "gnvm prune", "--keep-per-major --keep-lts --keep --older-than", "gnvm help prune")
+		return false
+	}
+	for _, r := range rule.Keep {
+		if _, err := util.MatchRange("0.0.0", r); err != nil {
+			P(ERROR, "%v %v %v\n", "--keep", r, err.Error())
+			return false
+		}
+	}
+	age, err := parseAge(rule.OlderThan)
+	if err != nil {
+		P(ERROR, "%v %v %v\n", "--older-than", rule.OlderThan, err.Error())
+		return false
+	}
+
+	versions, err := LS(false)
+	if err != nil {
+		return false
+	}
+
+	// sort by newest
+	sort.SliceStable(versions, func(i, j int) bool {
+		return util.CompareNodeVer(versions[i], versions[j]) > 0
+	})
+
+	// LTS codename of versions, when index unavailable, not prune, otherwise LTS versions would be removed
+	lts := map[string]string{}
+	if rule.KeepLTS {
+		for _, name := range versions {
+			ver, _, _, _ := util.ParseNodeVer(name)
+			if lts[name], err = ltsCodename(ver); err != nil {
+				P(ERROR, "%v can't get LTS versions, Error: %v, not any version removed.\n", "--keep-lts", err.Error())
+				return false
+			}
+		}
+	}
+
+	global, latest := config.GetConfig(config.GLOBAL_VERSION), config.GetConfig(config.LATEST_VERSION)
+	list, majors := &PruneList{DryRun: rule.DryRun, Items: []PruneItem{}}, map[string]int{}
+	for _, name := range versions {
+		ver, arch, _, _ := util.ParseNodeVer(name)
+		path := util.VersionPath(name)
+		item := PruneItem{Version: name, Path: path, Action: PRUNE_KEEP}
+		info, err := os.Stat(path)
+		if err == nil {
+			item.Modified = info.ModTime().Format("2006-01-02")
+		}
+		item.Size = folderSize(path)
+
+		// count newest n of major, e.g. 18-x64
+		major := strings.Split(ver, ".")[0] + "-" + util.DistArch(arch)
+		majors[major]++
+
+		switch {
+		case name == global:
+			item.Reason = "global version"
+		case name == latest:
+			item.Reason = "latest version"
+		case keepVersion(ver, rule.Keep) != "":
+			item.Reason = "--keep " + keepVersion(ver, rule.Keep)
+		case rule.KeepPerMajor > 0 && majors[major] <= rule.KeepPerMajor:
+			item.Reason = fmt.Sprintf("newest %v of major %v", rule.KeepPerMajor, major)
+		case lts[name] != "":
+			item.Reason = "LTS " + lts[name]
+		case age > 0 && (err != nil || time.Since(info.ModTime()) < age):
+			item.Reason = "newer than " + rule.OlderThan
+		default:
+			item.Action, item.Reason = PRUNE_REMOVE, "not match any keep rule"
+		}
+		list.Items = append(list.Items, item)
+	}
+
+	// remove
+	ok := true
+	for idx := range list.Items {
+		item := &list.Items[idx]
+		if item.Action != PRUNE_REMOVE {
+			continue
+		}
+		if !rule.DryRun {
+			if err := os.RemoveAll(item.Path); err != nil {
+				item.Reason, ok = "remove fail, Error: "+err.Error(), false
+				continue
+			}
+			item.Removed = true
+		}
+		list.Reclaimed += item.Size
+	}
+
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm prune", err.Error())
+		}
+		return ok
+	}
+
+	count := 0
+	for _, item := range list.Items {
+		switch {
+		case item.Action == PRUNE_KEEP:
+			P(DEFAULT, "keep   %-12v %10v  %v\n", item.Version, formatSize(item.Size), item.Reason)
+		case item.Removed:
+			count++
+			P(DEFAULT, "%v %-12v %10v  %v\n", CP{Red, false, None, false, "remove"}, item.Version, formatSize(item.Size), item.Modified)
+		case rule.DryRun:
+			count++
+			P(DEFAULT, "remove %-12v %10v  %v\n", item.Version, formatSize(item.Size), item.Modified)
+		default:
+			P(ERROR, "remove %v %v\n", item.Version, item.Reason)
+		}
+	}
+	switch {
+	case count == 0:
+		P(DEFAULT, "Not any version need to prune.\n")
+	case rule.DryRun:
+		P(NOTICE, "dry run, %v versions would be removed, reclaim %v. please run without %v to apply it.\n", count, formatSize(list.Reclaimed), "--dry-run")
+	default:
+		P(DEFAULT, "Prune success, %v versions removed, reclaimed %v.\n", count, formatSize(list.Reclaimed))
+	}
+	return ok
+}
+
+/*
+ Return the keep rule of version, empty is not match
+*/
+func keepVersion(ver string, keep []string) string {
+	for _, r := range keep {
+		if ok, _ := util.MatchRange(ver, r); ok {
+			return r
+		}
+	}
+	return ""
+}
+
+/*
+ Return LTS codename of version from remote index
+
+ Param:
+ 	- ver: Node.js version, e.g. 18.19.0
+
+ Return:
+ 	- codename, empty is not LTS or index not provide lts
+ 	- error:    get index error
+*/
+func ltsCodename(ver string) (string, error) {
+	// Node.js or io.js index, which index listed the version
+	_, _, nd, err := findRelease(config.GetConfig(config.REGISTRY), ver)
+	if err != nil || nd == nil {
+		return "", err
+	}
+	return nd.LTS, nil
+}
+
+/*
+ Parse age, support d( day ) w( week ) and time.ParseDuration, e.g. 180d 4w 72h
+
+ Return:
+ 	- duration, 0 is empty
+ 	- error
+*/
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if match := regexp.MustCompile(`^(\d+)([dw])$`).FindStringSubmatch(s); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		day := 24 * time.Hour
+		if match[2] == "w" {
+			day *= 7
+		}
+		return time.Duration(n) * day, nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age <= 0 {
+		return 0, errors.New("format error, e.g. 180d 4w 72h")
+	}
+	return age, nil
+}
+
+/*
+ Return total size of files in folder
+*/
+func folderSize(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+/*
+ Format bytes, e.g. 1.5 KB 45.2 MB
+*/
+func formatSize(size int64) string {
+	units, value := []string{"B", "KB", "MB", "GB"}, float64(size)
+	idx := 0
+	for value >= 1024 && idx < len(units)-1 {
+		value /= 1024
+		idx++
+	}
+	if idx == 0 {
+		return fmt.Sprintf("%v %v", size, units[idx])
+	}
+	return fmt.Sprintf("%.1f %v", value, units[idx])
+}
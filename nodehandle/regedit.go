@@ -18,12 +18,11 @@ import (
 
 const NODE_HOME, PATH = "NODE_HOME", "Path"
 
-var nodehome, noderoot string
+var nodehome string
 
 func init() {
-	noderoot = config.GetConfig(config.NODEROOT)
 	nodehome = os.Getenv(NODE_HOME)
-	if nodehome == "" && config.GetConfig(config.GLOBAL_VERSION) == util.UNKNOWN {
+	if nodehome == "" && !util.Portable && config.GetConfig(config.GLOBAL_VERSION) == util.UNKNOWN {
 		P(NOTICE, "not found environment variable '%v', please use '%v'. See '%v'.\n", NODE_HOME, "gnvm reg noderoot", "gnvm help reg")
 	}
 }
@@ -36,7 +35,13 @@ func init() {
 
 */
 func Reg(s string) {
-	prompt := "n"
+	prompt, noderoot := "n", config.GetConfig(config.NODEROOT)
+
+	// portable mode never touch HKCU\Environment
+	if util.Portable {
+		P(WARING, "current is %v, not support %v, please use '%v' print %v of this session.\n", "portable mode", "gnvm reg", "gnvm env", PATH)
+		return
+	}
 
 	P(WARING, "this command is %v, need %v permission, please note!\n", "experimental function", "Administrator")
 	if nodehome != "" {
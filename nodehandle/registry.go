@@ -0,0 +1,173 @@
+package nodehandle
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	// local
+	"gnvm/config"
+	"gnvm/util"
+)
+
+/*
+ Print all registry profiles, include active, latency and latest version, usage 'gnvm registry ls'
+*/
+func RegistryLs() {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm registry ls' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	profiles, registry := config.Profiles(), config.GetConfig(config.REGISTRY)
+	list := &RegistryList{Registries: make([]RegistryItem, len(profiles))}
+
+	// probe all registries concurrently
+	var wg sync.WaitGroup
+	for idx, profile := range profiles {
+		list.Registries[idx] = RegistryItem{Name: profile.Name, URL: profile.URL, Builtin: profile.Builtin, Active: profile.URL == registry, Latency: -1}
+		wg.Add(1)
+		go func(item *RegistryItem) {
+			defer wg.Done()
+			start := time.Now()
+			if latest := util.GetLatVer(item.URL + util.LATEST + "/" + util.SHASUMS); latest != "" {
+				item.Latest, item.Latency = latest, int64(time.Since(start)/time.Millisecond)
+			}
+		}(&list.Registries[idx])
+	}
+	wg.Wait()
+
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm registry ls", err.Error())
+		}
+		return
+	}
+
+	for _, item := range list.Registries {
+		name, latency, latest := fmt.Sprintf("  %-10v", item.Name), "unreachable", item.Latest
+		if item.Active {
+			name = fmt.Sprintf("* %-10v", item.Name)
+		}
+		if item.Latency >= 0 {
+			latency = fmt.Sprintf("%vms", item.Latency)
+		}
+		if item.Active {
+			P(DEFAULT, "%v %v %v %v\n", CP{Green, false, None, false, name}, util.Redact(item.URL), latency, latest)
+		} else {
+			P(DEFAULT, "%v %v %v %v\n", name, util.Redact(item.URL), latency, latest)
+		}
+	}
+	if active := config.ActiveProfile(); active == nil {
+		P(WARING, "current registry %v not in profiles, please use '%v'. See '%v'.\n", util.Redact(registry), "gnvm registry add <name> "+util.Redact(registry), "gnvm help registry")
+	}
+}
+
+/*
+ Probe all registry profiles concurrently, print ranked table, usage 'gnvm registry bench'
+
+ Param:
+ 	- apply:   true( set fastest registry to config registry )
+ 	- timeout: probe timeout of all registries
+*/
+func RegistryBench(apply bool, timeout time.Duration) {
+
+	// try catch
+	defer func() {
+		if err := recover(); err != nil {
+			Error(ERROR, "'gnvm registry bench' an error has occurred. please check. \nError: ", err)
+			os.Exit(0)
+		}
+	}()
+
+	artifact, registry := util.LATEST+"/"+util.SHASUMS, config.GetConfig(config.REGISTRY)
+	profiles := config.Profiles()
+	list := &BenchList{Artifact: artifact, Registries: make([]BenchItem, len(profiles))}
+
+	if !util.IsMachine() {
+		P(NOTICE, "benchmark %v registries with %v, timeout %v.\n", len(profiles), artifact, timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for idx, profile := range profiles {
+		list.Registries[idx] = BenchItem{Name: profile.Name, URL: profile.URL, Active: profile.URL == registry}
+		wg.Add(1)
+		go func(item *BenchItem) {
+			defer wg.Done()
+			result := util.Probe(ctx, http.MethodGet, item.URL+artifact)
+			if result.Err != nil {
+				item.Error = util.Redact(result.Err.Error())
+				return
+			}
+			item.Latency = int64(result.Latency / time.Millisecond)
+			item.Duration = int64(result.Duration / time.Millisecond)
+			item.Throughput = result.Throughput / 1024
+		}(&list.Registries[idx])
+	}
+	wg.Wait()
+
+	// rank, success first and sort by duration
+	sort.SliceStable(list.Registries, func(i, j int) bool {
+		a, b := list.Registries[i], list.Registries[j]
+		if (a.Error == "") != (b.Error == "") {
+			return a.Error == ""
+		}
+		return a.Duration < b.Duration
+	})
+	for idx := range list.Registries {
+		if list.Registries[idx].Error == "" {
+			list.Registries[idx].Rank = idx + 1
+		}
+	}
+
+	if util.IsMachine() {
+		if err := util.Render(list); err != nil {
+			P(ERROR, "'%v' Error: %v\n", "gnvm registry bench", err.Error())
+		}
+	} else {
+		for _, item := range list.Registries {
+			rank := "-"
+			if item.Rank > 0 {
+				rank = fmt.Sprintf("%v", item.Rank)
+			}
+			name := fmt.Sprintf("%-2v %-10v", rank, item.Name)
+			if item.Active {
+				name = fmt.Sprintf("%-2v %-10v", rank, "*"+item.Name)
+			}
+			if item.Error != "" {
+				P(DEFAULT, "%v %v %v\n", name, util.Redact(item.URL), CP{Red, false, None, false, item.Error})
+			} else {
+				P(DEFAULT, "%v %v latency %vms, duration %vms, %.1fKB/s\n", name, util.Redact(item.URL), item.Latency, item.Duration, item.Throughput)
+			}
+		}
+	}
+
+	if !apply {
+		return
+	}
+	if len(list.Registries) == 0 || list.Registries[0].Rank != 1 {
+		P(ERROR, "all registries unreachable, config %v not changed. See '%v'.\n", config.REGISTRY, "gnvm registry ls")
+		return
+	}
+	winner := list.Registries[0]
+	if winner.Active {
+		P(DEFAULT, "current registry %v is fastest, don't need to change.\n", winner.Name)
+	} else if newValue := config.SetConfig(config.REGISTRY, winner.URL); newValue != "" {
+		P(DEFAULT, "Set success, %v new value is %v ( %v )\n", config.REGISTRY, newValue, winner.Name)
+	}
+}
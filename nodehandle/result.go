@@ -0,0 +1,623 @@
+package nodehandle
+
+import (
+	// go
+	"strconv"
+	"strings"
+)
+
+/*
+ Structured result of read command, usage --output json|yaml|tsv and --format.
+ Field names( json and yaml key ) are stable, new field only append.
+*/
+type (
+	/*
+	 gnvm ls
+
+	 - root:     gnvm root path
+	 - versions: local Node.js version collection
+	*/
+	LocalList struct {
+		Root     string         `json:"root" yaml:"root"`
+		Versions []LocalVersion `json:"versions" yaml:"versions"`
+	}
+
+	/*
+	 - version: local folder name, e.g. 5.1.1 5.1.1-x86
+	 - node:    Node.js version, e.g. 5.1.1
+	 - arch:    x86 x64
+	 - flavor:  node iojs
+	 - global:  is global version
+	 - latest:  is latest version
+	*/
+	LocalVersion struct {
+		Version string `json:"version" yaml:"version"`
+		Node    string `json:"node" yaml:"node"`
+		Arch    string `json:"arch" yaml:"arch"`
+		Flavor  string `json:"flavor" yaml:"flavor"`
+		Global  bool   `json:"global" yaml:"global"`
+		Latest  bool   `json:"latest" yaml:"latest"`
+	}
+
+	/*
+	 gnvm ls -r, gnvm search
+
+	 - index:    remote index url
+	 - versions: remote Node.js version collection, sort by newest
+	*/
+	RemoteList struct {
+		Index    string          `json:"index" yaml:"index"`
+		Versions []RemoteVersion `json:"versions" yaml:"versions"`
+	}
+
+	/*
+	 - version: Node.js version, e.g. 5.9.0
+	 - date:    publish date, e.g. 2016-03-16
+	 - npm:     npm version, empty is unknown
+	 - exec:    node.exe arch collection, e.g. [x64 x86]
+	 - files:   index.json files, null is index not provide
+	*/
+	RemoteVersion struct {
+		Version string   `json:"version" yaml:"version"`
+		Date    string   `json:"date" yaml:"date"`
+		NPM     string   `json:"npm" yaml:"npm"`
+		Exec    []string `json:"exec" yaml:"exec"`
+		Files   []string `json:"files" yaml:"files"`
+	}
+
+	/*
+	 gnvm node-version
+
+	 - global:       .gnvmrc globalversion
+	 - latest:       .gnvmrc latestversion
+	 - remoteLatest: remote latest version, empty is not query or error
+	 - registry:     .gnvmrc registry
+	*/
+	NodeVersionResult struct {
+		Global       string `json:"global,omitempty" yaml:"global,omitempty"`
+		Latest       string `json:"latest,omitempty" yaml:"latest,omitempty"`
+		RemoteLatest string `json:"remoteLatest,omitempty" yaml:"remoteLatest,omitempty"`
+		Registry     string `json:"registry" yaml:"registry"`
+	}
+
+	/*
+	 gnvm version
+
+	 - version:   local gnvm version
+	 - arch:      32 bit, 64 bit
+	 - latest:    remote gnvm latest version, empty is not query
+	 - published: remote gnvm latest version publish date
+	 - upgrade:   must, suggest, optional or empty
+	 - changelog: CHANGELOG lines, only -d
+	*/
+	VersionResult struct {
+		Version   string   `json:"version" yaml:"version"`
+		Arch      string   `json:"arch" yaml:"arch"`
+		Latest    string   `json:"latest,omitempty" yaml:"latest,omitempty"`
+		Published string   `json:"published,omitempty" yaml:"published,omitempty"`
+		Upgrade   string   `json:"upgrade,omitempty" yaml:"upgrade,omitempty"`
+		Changelog []string `json:"changelog,omitempty" yaml:"changelog,omitempty"`
+	}
+
+	/*
+	 gnvm npm, when machine-readable output not download
+
+	 - local:  local npm version, unknown is not exist
+	 - remote: npm version of parameter( latest global x.xx.xx )
+	*/
+	NPMResult struct {
+		Local  string `json:"local" yaml:"local"`
+		Remote string `json:"remote" yaml:"remote"`
+	}
+
+	/*
+	 gnvm registry ls
+
+	 - registries: registry profile collection, built-in first
+	*/
+	RegistryList struct {
+		Registries []RegistryItem `json:"registries" yaml:"registries"`
+	}
+
+	/*
+	 - name:    profile name, e.g. DEFAULT corp
+	 - url:     registry url
+	 - builtin: is built-in profile
+	 - active:  is current registry
+	 - latency: latest/SHASUMS256.txt response time( ms ), -1 is unreachable
+	 - latest:  remote latest version, empty is unreachable
+	*/
+	RegistryItem struct {
+		Name    string `json:"name" yaml:"name"`
+		URL     string `json:"url" yaml:"url"`
+		Builtin bool   `json:"builtin" yaml:"builtin"`
+		Active  bool   `json:"active" yaml:"active"`
+		Latency int64  `json:"latency" yaml:"latency"`
+		Latest  string `json:"latest" yaml:"latest"`
+	}
+
+	/*
+	 gnvm registry bench
+
+	 - artifact:   probe artifact path of registry, e.g. latest/SHASUMS256.txt
+	 - registries: probe result collection, sort by rank
+	*/
+	BenchList struct {
+		Artifact   string      `json:"artifact" yaml:"artifact"`
+		Registries []BenchItem `json:"registries" yaml:"registries"`
+	}
+
+	/*
+	 - rank:       1 is fastest, 0 is fail
+	 - name:       profile name
+	 - url:        registry url
+	 - active:     is current registry
+	 - latency:    time to response header( ms )
+	 - duration:   time to download artifact( ms )
+	 - throughput: download speed( KB/s )
+	 - error:      fail reason, empty is success
+	*/
+	BenchItem struct {
+		Rank       int     `json:"rank" yaml:"rank"`
+		Name       string  `json:"name" yaml:"name"`
+		URL        string  `json:"url" yaml:"url"`
+		Active     bool    `json:"active" yaml:"active"`
+		Latency    int64   `json:"latency" yaml:"latency"`
+		Duration   int64   `json:"duration" yaml:"duration"`
+		Throughput float64 `json:"throughput" yaml:"throughput"`
+		Error      string  `json:"error,omitempty" yaml:"error,omitempty"`
+	}
+
+	/*
+	 gnvm registry check
+
+	 - registry:  checked registry url
+	 - version:   Node.js version, e.g. 18.19.0
+	 - arch:      x86 x64
+	 - ok:        true is all artifacts available
+	 - artifacts: artifact check collection
+	*/
+	CheckList struct {
+		Registry  string      `json:"registry" yaml:"registry"`
+		Version   string      `json:"version" yaml:"version"`
+		Arch      string      `json:"arch" yaml:"arch"`
+		OK        bool        `json:"ok" yaml:"ok"`
+		Artifacts []CheckItem `json:"artifacts" yaml:"artifacts"`
+	}
+
+	/*
+	 - name:    artifact name, e.g. node.exe SHASUMS256.txt npm
+	 - url:     artifact url
+	 - code:    HEAD response code, 0 is request fail
+	 - size:    Content-Length, -1 is unknown
+	 - listed:  index files include artifact, include: yes no unknown, empty is not index file
+	 - status:  ok missing mismatch error skip
+	 - message: detail of status
+	*/
+	CheckItem struct {
+		Name    string `json:"name" yaml:"name"`
+		URL     string `json:"url" yaml:"url"`
+		Code    int    `json:"code" yaml:"code"`
+		Size    int64  `json:"size" yaml:"size"`
+		Listed  string `json:"listed,omitempty" yaml:"listed,omitempty"`
+		Status  string `json:"status" yaml:"status"`
+		Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	}
+
+	/*
+	 gnvm migrate
+
+	 - dryRun: true is only print plan
+	 - items:  version folder, config and session script collection
+	*/
+	MigrateList struct {
+		DryRun bool          `json:"dryRun" yaml:"dryRun"`
+		Items  []MigrateItem `json:"items" yaml:"items"`
+	}
+
+	/*
+	 - kind:    version config session
+	 - name:    version name, config property or gns.cmd, e.g. 5.10.1-x86 globalversion
+	 - from:    old folder or value
+	 - to:      new folder or value
+	 - status:  plan ok skip error
+	 - message: detail of status
+	*/
+	MigrateItem struct {
+		Kind    string `json:"kind" yaml:"kind"`
+		Name    string `json:"name" yaml:"name"`
+		From    string `json:"from" yaml:"from"`
+		To      string `json:"to" yaml:"to"`
+		Status  string `json:"status" yaml:"status"`
+		Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	}
+
+	/*
+	 gnvm env
+
+	 - home:     gnvm data folder
+	 - portable: is portable mode
+	 - vars:     environment variable collection, PATH value is prepend folders
+	*/
+	EnvList struct {
+		Home     string   `json:"home" yaml:"home"`
+		Portable bool     `json:"portable" yaml:"portable"`
+		Vars     []EnvVar `json:"vars" yaml:"vars"`
+	}
+
+	/*
+	 - name:  environment variable, e.g. NODE_HOME
+	 - value: e.g. E:\gnvm\global
+	*/
+	EnvVar struct {
+		Name  string `json:"name" yaml:"name"`
+		Value string `json:"value" yaml:"value"`
+	}
+
+	/*
+	 gnvm policy
+
+	 - path:       policy file, empty is not any policy
+	 - allow:      allowed version ranges, empty is all
+	 - block:      blocked versions or ranges
+	 - registries: permitted registry url prefixes, empty is all
+	 - verify:     node.exe must be verified against SHASUMS256.txt
+	*/
+	PolicyResult struct {
+		Path       string   `json:"path" yaml:"path"`
+		Allow      []string `json:"allow" yaml:"allow"`
+		Block      []string `json:"block" yaml:"block"`
+		Registries []string `json:"registries" yaml:"registries"`
+		Verify     bool     `json:"verify" yaml:"verify"`
+	}
+
+	/*
+	 gnvm doctor
+
+	 - fix:   true is --fix
+	 - ok:    true is not any unfixed error
+	 - items: finding collection
+	*/
+	DoctorList struct {
+		Fix   bool         `json:"fix" yaml:"fix"`
+		OK    bool         `json:"ok" yaml:"ok"`
+		Items []DoctorItem `json:"items" yaml:"items"`
+	}
+
+	/*
+	 - check:    path nodehome global latest folder session npmzip npm
+	 - severity: ok info warning error
+	 - message:  detail of finding
+	 - fix:      safe repair or suggestion
+	 - fixed:    true is repaired by --fix
+	*/
+	DoctorItem struct {
+		Check    string `json:"check" yaml:"check"`
+		Severity string `json:"severity" yaml:"severity"`
+		Message  string `json:"message" yaml:"message"`
+		Fix      string `json:"fix,omitempty" yaml:"fix,omitempty"`
+		Fixed    bool   `json:"fixed" yaml:"fixed"`
+	}
+
+	/*
+	 gnvm prune
+
+	 - dryRun:    true is only print plan
+	 - reclaimed: disk space of removed folders( byte ), dry run is would be reclaimed
+	 - items:     installed version collection, sort by newest
+	*/
+	PruneList struct {
+		DryRun    bool        `json:"dryRun" yaml:"dryRun"`
+		Reclaimed int64       `json:"reclaimed" yaml:"reclaimed"`
+		Items     []PruneItem `json:"items" yaml:"items"`
+	}
+
+	/*
+	 - version:  version name, e.g. 5.10.1 5.10.1-x86
+	 - path:     version folder
+	 - size:     folder size( byte )
+	 - modified: folder modified date, e.g. 2016-03-16
+	 - action:   keep remove
+	 - reason:   matched keep rule or remove error
+	 - removed:  true is removed
+	*/
+	PruneItem struct {
+		Version  string `json:"version" yaml:"version"`
+		Path     string `json:"path" yaml:"path"`
+		Size     int64  `json:"size" yaml:"size"`
+		Modified string `json:"modified" yaml:"modified"`
+		Action   string `json:"action" yaml:"action"`
+		Reason   string `json:"reason" yaml:"reason"`
+		Removed  bool   `json:"removed" yaml:"removed"`
+	}
+
+	/*
+	 - command: command and arguments, e.g. npm test
+	 - logs:    logs folder of this run
+	 - items:   result of each version
+	*/
+	MatrixList struct {
+		Command string       `json:"command" yaml:"command"`
+		Logs    string       `json:"logs" yaml:"logs"`
+		Items   []MatrixItem `json:"items" yaml:"items"`
+	}
+
+	/*
+	 - version:  version name, e.g. 5.10.1 5.10.1-x86
+	 - result:   pass fail error
+	 - code:     exit code of command, -1 is not run
+	 - duration: run time, e.g. 1.52s
+	 - log:      log file of stdout and stderr
+	 - error:    fail reason
+	*/
+	MatrixItem struct {
+		Version  string `json:"version" yaml:"version"`
+		Result   string `json:"result" yaml:"result"`
+		Code     int    `json:"code" yaml:"code"`
+		Duration string `json:"duration" yaml:"duration"`
+		Log      string `json:"log" yaml:"log"`
+		Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+	}
+
+	/*
+	 - from:   source version name, e.g. 18.19.0
+	 - to:     target version name, e.g. 20.11.0
+	 - prefix: npm prefix of target version
+	 - items:  global packages of source version
+	*/
+	PackageList struct {
+		From   string        `json:"from" yaml:"from"`
+		To     string        `json:"to" yaml:"to"`
+		Prefix string        `json:"prefix" yaml:"prefix"`
+		Items  []PackageItem `json:"items" yaml:"items"`
+	}
+
+	/*
+	 gnvm install
+
+	 - items: installed version collection
+	*/
+	InstallList struct {
+		Items []InstallItem `json:"items" yaml:"items"`
+	}
+
+	/*
+	 - version:  installed version name, e.g. 18.19.0 18.19.0-x86
+	 - path:     version folder
+	 - result:   ok fail, fail is some default packages not installed
+	 - message:  fail reason
+	 - packages: default packages result
+	*/
+	InstallItem struct {
+		Version  string        `json:"version" yaml:"version"`
+		Path     string        `json:"path" yaml:"path"`
+		Result   string        `json:"result" yaml:"result"`
+		Message  string        `json:"message,omitempty" yaml:"message,omitempty"`
+		Packages []PackageItem `json:"packages" yaml:"packages"`
+	}
+
+	/*
+	 - name:    package name, e.g. typescript
+	 - version: version of source, empty is unknown
+	 - spec:    install spec, e.g. typescript typescript@5.3.3
+	 - result:  ok fail skip plan
+	 - message: skip reason or fail error
+	*/
+	PackageItem struct {
+		Name    string `json:"name" yaml:"name"`
+		Version string `json:"version" yaml:"version"`
+		Spec    string `json:"spec" yaml:"spec"`
+		Result  string `json:"result" yaml:"result"`
+		Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	}
+)
+
+func (this *LocalList) Header() []string {
+	return []string{"version", "node", "arch", "flavor", "global", "latest"}
+}
+
+func (this *LocalList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Versions {
+		rows = append(rows, []string{v.Version, v.Node, v.Arch, v.Flavor, strconv.FormatBool(v.Global), strconv.FormatBool(v.Latest)})
+	}
+	return rows
+}
+
+func (this *RemoteList) Header() []string {
+	return []string{"version", "date", "npm", "exec"}
+}
+
+func (this *RemoteList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Versions {
+		rows = append(rows, []string{v.Version, v.Date, v.NPM, strings.Join(v.Exec, ",")})
+	}
+	return rows
+}
+
+func (this *NodeVersionResult) Header() []string {
+	return []string{"global", "latest", "remoteLatest", "registry"}
+}
+
+func (this *NodeVersionResult) Rows() [][]string {
+	return [][]string{{this.Global, this.Latest, this.RemoteLatest, this.Registry}}
+}
+
+func (this *VersionResult) Header() []string {
+	return []string{"version", "arch", "latest", "published", "upgrade"}
+}
+
+func (this *VersionResult) Rows() [][]string {
+	return [][]string{{this.Version, this.Arch, this.Latest, this.Published, this.Upgrade}}
+}
+
+func (this *NPMResult) Header() []string {
+	return []string{"local", "remote"}
+}
+
+func (this *NPMResult) Rows() [][]string {
+	return [][]string{{this.Local, this.Remote}}
+}
+
+func (this *RegistryList) Header() []string {
+	return []string{"name", "url", "builtin", "active", "latency", "latest"}
+}
+
+func (this *RegistryList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Registries {
+		rows = append(rows, []string{v.Name, v.URL, strconv.FormatBool(v.Builtin), strconv.FormatBool(v.Active), strconv.FormatInt(v.Latency, 10), v.Latest})
+	}
+	return rows
+}
+
+func (this *BenchList) Header() []string {
+	return []string{"rank", "name", "url", "active", "latency", "duration", "throughput", "error"}
+}
+
+func (this *BenchList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Registries {
+		rows = append(rows, []string{strconv.Itoa(v.Rank), v.Name, v.URL, strconv.FormatBool(v.Active), strconv.FormatInt(v.Latency, 10), strconv.FormatInt(v.Duration, 10), strconv.FormatFloat(v.Throughput, 'f', 1, 64), v.Error})
+	}
+	return rows
+}
+
+func (this *CheckList) Header() []string {
+	return []string{"name", "url", "code", "size", "listed", "status", "message"}
+}
+
+func (this *CheckList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Artifacts {
+		rows = append(rows, []string{v.Name, v.URL, strconv.Itoa(v.Code), strconv.FormatInt(v.Size, 10), v.Listed, v.Status, v.Message})
+	}
+	return rows
+}
+
+func (this *MigrateList) Header() []string {
+	return []string{"kind", "name", "from", "to", "status", "message"}
+}
+
+func (this *MigrateList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Items {
+		rows = append(rows, []string{v.Kind, v.Name, v.From, v.To, v.Status, v.Message})
+	}
+	return rows
+}
+
+func (this *EnvList) Header() []string {
+	return []string{"name", "value"}
+}
+
+func (this *EnvList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Vars {
+		rows = append(rows, []string{v.Name, v.Value})
+	}
+	return rows
+}
+
+func (this *PolicyResult) Header() []string {
+	return []string{"path", "allow", "block", "registries", "verify"}
+}
+
+func (this *PolicyResult) Rows() [][]string {
+	return [][]string{{this.Path, strings.Join(this.Allow, ","), strings.Join(this.Block, ","), strings.Join(this.Registries, ","), strconv.FormatBool(this.Verify)}}
+}
+
+func (this *DoctorList) Header() []string {
+	return []string{"check", "severity", "message", "fix", "fixed"}
+}
+
+func (this *DoctorList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Items {
+		rows = append(rows, []string{v.Check, v.Severity, v.Message, v.Fix, strconv.FormatBool(v.Fixed)})
+	}
+	return rows
+}
+
+func (this *PruneList) Header() []string {
+	return []string{"version", "path", "size", "modified", "action", "reason", "removed"}
+}
+
+func (this *PruneList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Items {
+		rows = append(rows, []string{v.Version, v.Path, strconv.FormatInt(v.Size, 10), v.Modified, v.Action, v.Reason, strconv.FormatBool(v.Removed)})
+	}
+	return rows
+}
+
+func (this *MatrixList) Header() []string {
+	return []string{"version", "result", "code", "duration", "log", "error"}
+}
+
+func (this *MatrixList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Items {
+		rows = append(rows, []string{v.Version, v.Result, strconv.Itoa(v.Code), v.Duration, v.Log, v.Error})
+	}
+	return rows
+}
+
+func (this *InstallList) Header() []string {
+	return []string{"version", "path", "result", "packages"}
+}
+
+func (this *InstallList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Items {
+		packages := []string{}
+		for _, pkg := range v.Packages {
+			packages = append(packages, pkg.Spec+":"+pkg.Result)
+		}
+		rows = append(rows, []string{v.Version, v.Path, v.Result, strings.Join(packages, ",")})
+	}
+	return rows
+}
+
+func (this *PackageList) Header() []string {
+	return []string{"name", "version", "spec", "result", "message"}
+}
+
+func (this *PackageList) Rows() [][]string {
+	var rows [][]string
+	for _, v := range this.Items {
+		rows = append(rows, []string{v.Name, v.Version, v.Spec, v.Result, v.Message})
+	}
+	return rows
+}
+
+/*
+ Conver Nodist to RemoteList
+
+ Param:
+    - index: remote index url
+    - limit: max rows, when limit == 0, all
+
+ Return:
+    - *RemoteList
+
+*/
+func (this *Nodist) Result(index string, limit int) *RemoteList {
+	list := &RemoteList{Index: index, Versions: []RemoteVersion{}}
+	for idx, v := range this.Sorts {
+		if limit > 0 && idx >= limit {
+			break
+		}
+		nd := this.nl[v]
+		rv := RemoteVersion{Version: v[1:], Date: nd.Date, Files: nd.Files, Exec: []string{}}
+		if nd.NPM.Version != "[x]" {
+			rv.NPM = nd.NPM.Version
+		}
+		if nd.Node.Exec != "[x]" && nd.Node.Exec != "[?]" {
+			rv.Exec = strings.Fields(nd.Node.Exec)
+		}
+		list.Versions = append(list.Versions, rv)
+	}
+	return list
+}
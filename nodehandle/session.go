@@ -7,6 +7,8 @@ import (
 	// go
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
 
 	// local
 	"gnvm/util"
@@ -25,6 +27,8 @@ if not defined NODE_HOME (
     echo Waring: NODE_HOME is't not defined.
     echo NODE_HOME create success, it's value is %cd%
 )
+set "GNVM_VERSIONS={{GNVM_VERSIONS}}"
+set "GNVM_ARCH={{GNVM_ARCH}}"
 
 ::===========================================================
 :: Logic
@@ -78,8 +82,14 @@ if "%2" == "" (
     goto exit
 )
 
-if not exist "%NODE_HOME%\%2" (
-    echo Waring: "%NODE_HOME%\%2\" directory not exist.
+:: version folder is <versions>\<flavor>\<version>-<arch>, e.g. 5.10.1 -> node\5.10.1-x64, 5.10.1-x86 -> node\5.10.1-x86
+set "GNVM_VERSION_PATH="
+for %%f in (node iojs) do (
+    if exist "%GNVM_VERSIONS%\%%f\%2-%GNVM_ARCH%\node.exe" set "GNVM_VERSION_PATH=%GNVM_VERSIONS%\%%f\%2-%GNVM_ARCH%"
+    if exist "%GNVM_VERSIONS%\%%f\%2\node.exe" set "GNVM_VERSION_PATH=%GNVM_VERSIONS%\%%f\%2"
+)
+if not defined GNVM_VERSION_PATH (
+    echo Waring: Node.js version %2 directory not exist in "%GNVM_VERSIONS%".
     echo Notice: you can usage "gnvm ls" check local exist Node.js version.
     goto exit
 )
@@ -87,7 +97,7 @@ if not exist "%NODE_HOME%\%2" (
 :: if on the %NODE_HOME% directory, goto gnvm_session directory.
 if "%cd%" == "%NODE_HOME%" call :security
 
-set GNVM_SESSION_NODE_HOME=%NODE_HOME%\%2\
+set GNVM_SESSION_NODE_HOME=%GNVM_VERSION_PATH%\
 set path=%GNVM_SESSION_NODE_HOME%;%path%
 
 echo Startup Node.js version %2 session environment.
@@ -144,7 +154,10 @@ goto exit
 exit /b 0
 
 `
-var GNS_HOME = util.GlobalNodePath + util.DIVIDE + "gns.cmd"
+const GNS = "gns.cmd"
+
+// <noderoot>\gns.cmd, set by nodehandle init
+var GNS_HOME string
 
 /*
  Regedit
@@ -164,7 +177,7 @@ func Run(action string) {
 		}
 	}()
 
-	if _, err := util.GetNodeVer(util.GlobalNodePath); err != nil {
+	if _, err := util.GetNodeVer(rootPath); err != nil {
 		P(ERROR, "not found %v node.exe, not use %v. please use '%v'. See '%v'.\n", "global", "gnvm session "+action, "gnvm install x.xx.xx -g", "gnvm help install")
 		return
 	}
@@ -186,7 +199,7 @@ func start() {
 			return
 		}
 	}
-	if _, err := file.WriteString(batFileContent); err == nil {
+	if _, err := file.WriteString(gnsContent()); err == nil {
 		P(NOTICE, "sesson environment %v, path is %v.\n", "start success", GNS_HOME)
 		P(NOTICE, "please use '%v'. See '%v' or '%v'.\n", "gns run x.xx.xx", "gnvm help session", "gns help")
 	}
@@ -201,3 +214,10 @@ func close() {
 		P(NOTICE, "sesson environment %v.\n", "close success")
 	}
 }
+
+/*
+ Return gns.cmd content of current versions folder and arch
+*/
+func gnsContent() string {
+	return strings.NewReplacer("{{GNVM_VERSIONS}}", util.VersionsPath, "{{GNVM_ARCH}}", util.DistArch(runtime.GOARCH)).Replace(batFileContent)
+}
@@ -0,0 +1,348 @@
+package util
+
+import (
+	// go
+	"errors"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+const (
+	FLAVOR_NODE = "node"
+	FLAVOR_IOJS = "iojs"
+)
+
+/*
+ Release file entry of index.json `files` property, e.g.
+	- win-x64-exe
+	- win-x86-zip
+	- linux-arm64
+	- osx-x64-tar
+	- headers, src
+
+ OS:   win osx linux sunos aix ..., empty when entry is "headers" or "src"
+ Arch: x64 x86 arm64 armv7l ppc64le ...
+ Kind: exe zip 7z msi tar pkg, empty is tarball( e.g. linux-x64 )
+*/
+type DistFile struct {
+	Entry string
+	OS    string
+	Arch  string
+	Kind  string
+}
+
+/*
+ Parse index.json files entry
+
+ Param:
+	- entry: e.g. win-x64-exe
+
+ Return:
+	- DistFile
+*/
+func ParseDistFile(entry string) DistFile {
+	df := DistFile{Entry: entry}
+	arr := strings.SplitN(entry, "-", 3)
+	if len(arr) == 1 {
+		df.Kind = arr[0]
+		return df
+	}
+	df.OS, df.Arch = arr[0], arr[1]
+	if len(arr) == 3 {
+		df.Kind = arr[2]
+	}
+	return df
+}
+
+/*
+ Return remote file name of DistFile, relative to <url>/v<version>/, e.g.
+	- win-x64-exe  -> win-x64/node.exe
+	- win-x86-zip  -> node-v5.9.0-win-x86.zip
+	- linux-arm64  -> node-v5.9.0-linux-arm64.tar.gz
+	- osx-x64-tar  -> node-v5.9.0-darwin-x64.tar.gz
+
+ Param:
+	- flavor:  FLAVOR_NODE or FLAVOR_IOJS
+	- version: Node.js version, e.g. 5.9.0
+
+ Return:
+	- name: file name, empty when entry unknown
+*/
+func (this DistFile) Name(flavor, version string) string {
+	prefix := flavor + "-v" + version
+	os := this.OS
+	if os == "osx" {
+		os = "darwin"
+	}
+	switch this.Kind {
+	case "exe":
+		// only Node.js 0.x x86 exe is <root>/node.exe and x64 exe is x64/node.exe
+		if flavor == FLAVOR_NODE && strings.HasPrefix(version, "0.") {
+			if this.Arch == "x86" {
+				return NODE
+			}
+			return this.Arch + "/" + NODE
+		}
+		return this.OS + "-" + this.Arch + "/" + flavor + ".exe"
+	case "zip", "7z":
+		return prefix + "-" + os + "-" + this.Arch + "." + this.Kind
+	case "msi":
+		return prefix + "-" + this.Arch + ".msi"
+	case "tar":
+		return prefix + "-" + os + "-" + this.Arch + ".tar.gz"
+	case "pkg":
+		return prefix + ".pkg"
+	case "headers":
+		return prefix + "-headers.tar.gz"
+	case "src":
+		return prefix + ".tar.gz"
+	case "":
+		return prefix + "-" + os + "-" + this.Arch + ".tar.gz"
+	}
+	return ""
+}
+
+/*
+ Download url template variables, e.g. {base}/{version}/{flavor}-{version}-{os}-{arch}.{ext}
+
+ - base:    registry url without the end /, e.g. https://bucket.s3.amazonaws.com/node
+ - version: version with v prefix, e.g. v18.19.0
+ - semver:  version without v prefix, e.g. 18.19.0
+ - flavor:  node iojs
+ - os:      index.json os, e.g. win linux osx
+ - arch:    index.json arch, e.g. x86 x64
+ - ext:     file extension, e.g. exe zip 7z msi tar.gz
+*/
+var templateVars = []string{"base", "version", "semver", "flavor", "os", "arch", "ext"}
+
+/*
+ Verify download url template, only support templateVars and must include {version} or {semver}
+
+ Param:
+	- tmpl: url template, empty is standard layout of nodejs.org
+
+ Return:
+	- error
+*/
+func VerifyTemplate(tmpl string) error {
+	if tmpl == "" {
+		return nil
+	}
+	reg := regexp.MustCompile(`\{([^{}]*)\}`)
+	for _, match := range reg.FindAllStringSubmatch(tmpl, -1) {
+		known := false
+		for _, name := range templateVars {
+			if match[1] == name {
+				known = true
+			}
+		}
+		if !known {
+			return errors.New("unknown template variable " + match[0] + ", support: {" + strings.Join(templateVars, "} {") + "}")
+		}
+	}
+	if !strings.Contains(tmpl, "{version}") && !strings.Contains(tmpl, "{semver}") {
+		return errors.New("template must include {version} or {semver}.")
+	}
+	if !strings.HasPrefix(tmpl, "{base}") && !strings.HasPrefix(tmpl, "http://") && !strings.HasPrefix(tmpl, "https://") {
+		return errors.New("template must start with {base} or http(s)://.")
+	}
+	return nil
+}
+
+/*
+ Expand download url template of DistFile, e.g.
+	- {base}/{version}/{flavor}-{version}-{os}-{arch}.{ext} win-x64-exe -> https://bucket/node/v18.19.0/node-v18.19.0-win-x64.exe
+
+ Param:
+	- tmpl:    url template, empty is standard layout <url>/v<version>/<DistFile.Name>
+	- url:     registry url, e.g. http://nodejs.org/dist/
+	- flavor:  FLAVOR_NODE or FLAVOR_IOJS
+	- version: Node.js version, e.g. 18.19.0
+
+ Return:
+	- url: remote file url
+*/
+func (this DistFile) URL(tmpl, url, flavor, version string) string {
+	if tmpl == "" {
+		return url + "v" + version + "/" + this.Name(flavor, version)
+	}
+	ext := this.Kind
+	if ext == "tar" || ext == "" {
+		ext = "tar.gz"
+	}
+	return strings.NewReplacer(
+		"{base}", strings.TrimSuffix(url, "/"),
+		"{version}", "v"+version,
+		"{semver}", version,
+		"{flavor}", flavor,
+		"{os}", this.OS,
+		"{arch}", this.Arch,
+		"{ext}", ext,
+	).Replace(tmpl)
+}
+
+/*
+ Return SHASUMS256.txt url and file name in it of DistFile, same layout as URL, e.g.
+	- "" win-x64-exe -> http://nodejs.org/dist/v18.19.0/SHASUMS256.txt win-x64/node.exe
+	- {base}/{version}/{flavor}-{version}-{os}-{arch}.{ext} -> https://bucket/node/v18.19.0/SHASUMS256.txt node-v18.19.0-win-x64.exe
+
+ SHASUMS256.txt is in the folder of first path segment include {version} or {semver}, when it is file name, folder of file.
+
+ Param:
+	- tmpl:    url template, empty is standard layout
+	- url:     registry url
+	- flavor:  FLAVOR_NODE or FLAVOR_IOJS
+	- version: Node.js version, e.g. 18.19.0
+
+ Return:
+	- sums: SHASUMS256.txt url
+	- name: file name in SHASUMS256.txt
+*/
+func (this DistFile) Checksums(tmpl, url, flavor, version string) (string, string) {
+	if tmpl == "" {
+		return url + "v" + version + "/" + SHASUMS, this.Name(flavor, version)
+	}
+	segments, folder := strings.Split(tmpl, "/"), -1
+	for idx, segment := range segments {
+		if strings.Contains(segment, "{version}") || strings.Contains(segment, "{semver}") {
+			folder = idx
+			break
+		}
+	}
+	if folder == -1 || folder == len(segments)-1 {
+		folder = len(segments) - 2
+	}
+	dir := this.URL(strings.Join(segments[:folder+1], "/"), url, flavor, version)
+	remote := this.URL(tmpl, url, flavor, version)
+	return dir + "/" + SHASUMS, strings.TrimPrefix(remote, dir+"/")
+}
+
+/*
+ Conver go os and arch to index.json files entry, e.g.
+	- windows amd64 exe -> win-x64-exe
+	- linux   arm64 ""  -> linux-arm64
+
+ Param:
+	- goos:   runtime.GOOS
+	- goarch: runtime.GOARCH, include: "386" "amd64" "arm" "arm64"
+	- kind:   exe zip 7z msi tar pkg or ""
+
+ Return:
+	- entry
+*/
+func DistEntry(goos, goarch, kind string) string {
+	os, arch := goos, DistArch(goarch)
+	switch goos {
+	case "windows":
+		os = "win"
+	case "darwin":
+		os = "osx"
+	}
+	if kind == "" {
+		return os + "-" + arch
+	}
+	return os + "-" + arch + "-" + kind
+}
+
+/*
+ Conver go arch to index.json arch
+
+ Param:
+	- goarch: runtime.GOARCH, include: "386" "amd64" "arm" "arm64"
+
+ Return:
+	- arch:   e.g. x86 x64 armv7l arm64
+*/
+func DistArch(goarch string) string {
+	switch goarch {
+	case "386":
+		return "x86"
+	case "amd64":
+		return "x64"
+	case "arm":
+		return "armv7l"
+	}
+	return goarch
+}
+
+/*
+ Get index.json files entry of node.exe for current os
+
+ Param:
+	- arch: "386" and "amd64"
+
+ Return:
+	- entry, e.g. win-x64-exe
+*/
+func ExeEntry(arch string) string {
+	return DistEntry(runtime.GOOS, arch, "exe")
+}
+
+/*
+ Find entry from index.json files entry
+
+ Param:
+	- files: index.json files
+	- entry: e.g. win-x64-exe
+
+ Return:
+	- true( exist ) false( not exist )
+*/
+func HasDistFile(files []string, entry string) bool {
+	for _, v := range files {
+		if v == entry {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ Return all arch of the kind exist from index.json files entry
+
+ Param:
+	- files: index.json files
+	- os:    index.json os, e.g. win linux osx
+	- kind:  e.g. exe zip
+
+ Return:
+	- arch:  e.g. [x86 x64]
+*/
+func DistArchs(files []string, os, kind string) []string {
+	var archs []string
+	for _, v := range files {
+		if df := ParseDistFile(v); df.OS == os && df.Kind == kind {
+			archs = append(archs, df.Arch)
+		}
+	}
+	return archs
+}
+
+/*
+ Verify the release provide the entry of index.json files
+
+ Param:
+	- version: Node.js version
+	- files:   index.json files
+	- entry:   e.g. win-x64-exe
+
+ Return:
+	- error: include the all available entry of the same os
+*/
+func VerifyDistFile(version string, files []string, entry string) error {
+	if HasDistFile(files, entry) {
+		return nil
+	}
+	df := ParseDistFile(entry)
+	var available []string
+	for _, v := range files {
+		if ParseDistFile(v).OS == df.OS {
+			available = append(available, v)
+		}
+	}
+	if len(available) == 0 {
+		return errors.New("version " + version + " not provide " + entry + ", not any " + df.OS + " file.")
+	}
+	return errors.New("version " + version + " not provide " + entry + ", available: " + strings.Join(available, " "))
+}
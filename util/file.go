@@ -0,0 +1,134 @@
+package util
+
+import (
+	// go
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const FILE_SCHEME = "file"
+
+/*
+ Serve file:// url from local filesystem, registered to http.DefaultTransport, so curl.Get curl.New and Probe
+ read index, latest version, SHASUMS256.txt and node.exe from a file share same as HTTP registry
+
+ - file exist:      200, HEAD include Content-Length
+ - folder:          200, html directory listing
+ - not exist:       404
+*/
+type fileTransport struct{}
+
+func (this fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := http.NewFileTransport(localFS{req.URL.Host}).RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	// http.NewFileTransport not set ContentLength from header
+	if size, err := strconv.ParseInt(res.Header.Get("Content-Length"), 10, 64); err == nil {
+		res.ContentLength = size
+	}
+	return res, nil
+}
+
+/*
+ http.FileSystem of file:// url, host is UNC server name on Windows, e.g. file://server/share/node/
+*/
+type localFS struct {
+	host string
+}
+
+func (this localFS) Open(name string) (http.File, error) {
+	return os.Open(FilePath(this.host, name))
+}
+
+func init() {
+	baseTransport.RegisterProtocol(FILE_SCHEME, fileTransport{})
+}
+
+/*
+ Judge value is local folder or file path, e.g. D:\nodedist \\server\share\nodedist /mnt/nodedist
+
+ Param:
+	- value: registry value
+
+ Return:
+	- true( path ) false( url or host )
+*/
+func IsLocalPath(value string) bool {
+	if strings.HasPrefix(value, `\\`) {
+		return true
+	}
+	if runtime.GOOS == "windows" {
+		if ok, _ := regexp.MatchString(`^[a-zA-Z]:[\\/]`, value); ok {
+			return true
+		}
+	}
+	return strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "//")
+}
+
+/*
+ Conver local path to file url, e.g.
+	- D:\nodedist\               -> file:///D:/nodedist/
+	- \\server\share\nodedist\   -> file://server/share/nodedist/
+	- /mnt/nodedist/             -> file:///mnt/nodedist/
+
+ Param:
+	- path: absolute local path
+
+ Return:
+	- file url
+*/
+func FileURL(path string) string {
+	slash := strings.Replace(path, `\`, "/", -1)
+	if strings.HasPrefix(slash, "//") {
+		return FILE_SCHEME + ":" + slash
+	}
+	if !strings.HasPrefix(slash, "/") {
+		slash = "/" + slash
+	}
+	return FILE_SCHEME + "://" + slash
+}
+
+/*
+ Conver file url host and path to local path, e.g.
+	- "" /D:/nodedist/index.json        -> D:\nodedist\index.json
+	- server /share/nodedist/index.json -> \\server\share\nodedist\index.json
+
+ Param:
+	- host: file url host, empty or localhost is local disk
+	- path: file url path
+
+ Return:
+	- local path
+*/
+func FilePath(host, path string) string {
+	if host != "" && host != "localhost" {
+		path = "//" + host + path
+	} else if ok, _ := regexp.MatchString(`^/[a-zA-Z]:`, path); ok {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+/*
+ Return local path of file url
+
+ Param:
+	- raw: file url, e.g. file:///D:/nodedist/
+
+ Return:
+	- local path, empty is not file url
+*/
+func LocalPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != FILE_SCHEME {
+		return ""
+	}
+	return FilePath(u.Host, u.Path)
+}
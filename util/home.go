@@ -0,0 +1,231 @@
+package util
+
+import (
+	// go
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	HOME_ENV = "GNVM_HOME"
+	PORTABLE = "portable"
+
+	VERSIONS = "versions"
+	CACHE    = "cache"
+	LOGS     = "logs"
+)
+
+/*
+ gnvm data folder, from --home, portable marker or env GNVM_HOME, empty is legacy layout( home is GlobalNodePath )
+
+ <GNVM_HOME>\
+ 	.gnvmrc
+ 	portable                            marker file of portable mode, home is gnvm.exe folder
+ 	global\                             global node.exe, default of config noderoot
+ 	versions\<flavor>\<version>-<arch>\  installed Node.js versions, e.g. versions\node\18.19.0-x64\
+ 	cache\
+ 	logs\
+*/
+var GnvmHome string
+
+/*
+ Portable mode, file 'portable' next to gnvm.exe, home is gnvm.exe folder, e.g. a USB stick or network share
+ never touch the registry, user profile and HKCU\Environment, relative path of config is relative to home
+*/
+var Portable bool
+
+var (
+	// .gnvmrc, lock and credential file folder
+	HomePath string
+	// installed Node.js versions folder
+	VersionsPath string
+	CachePath    string
+	LogsPath     string
+)
+
+/*
+ Initialize gnvm data folder layout, --home > portable marker > GNVM_HOME > legacy global node.exe discovery
+*/
+func initHome() {
+	home, ok := ArgValue("--home")
+	if !ok || home == "" {
+		if dir := ExeDir(); dir != "" && IsDirExist(dir, PORTABLE) {
+			home, Portable = dir, true
+		} else {
+			home = os.Getenv(HOME_ENV)
+		}
+	}
+
+	if home == "" {
+		GlobalNodePath = getGlobalNodePath()
+		HomePath, VersionsPath = GlobalNodePath, filepath.Join(GlobalNodePath, VERSIONS)
+		CachePath, LogsPath = filepath.Join(HomePath, CACHE), filepath.Join(HomePath, LOGS)
+		return
+	}
+
+	home, err := filepath.Abs(home)
+	if err != nil {
+		panic("get " + HOME_ENV + " absolute path Error: " + err.Error())
+	}
+	GnvmHome, HomePath = home, home
+	GlobalNodePath = filepath.Join(home, GLOBAL)
+	VersionsPath = filepath.Join(home, VERSIONS)
+	CachePath, LogsPath = filepath.Join(home, CACHE), filepath.Join(home, LOGS)
+	for _, path := range []string{GlobalNodePath, VersionsPath, CachePath, LogsPath} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			panic("create " + path + " folder Error: " + err.Error())
+		}
+	}
+}
+
+/*
+ Return gnvm.exe folder, empty is unknown
+*/
+func ExeDir() string {
+	path, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	return filepath.Dir(path)
+}
+
+/*
+ Return absolute path, relative path is relative to home in portable mode, e.g. global -> E:\gnvm\global
+
+ Param:
+ 	- path: config path value
+*/
+func ResolvePath(path string) string {
+	if Portable && path != "" && !filepath.IsAbs(path) {
+		return filepath.Join(HomePath, path)
+	}
+	return path
+}
+
+/*
+ Return path relative to home in portable mode, so drive letter of USB stick can change, e.g. E:\gnvm\global -> global
+
+ Param:
+ 	- path: absolute path
+*/
+func PortablePath(path string) string {
+	if !Portable {
+		return path
+	}
+	if rel, err := filepath.Rel(HomePath, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return rel
+	}
+	return path
+}
+
+/*
+ Return installed folder of version, <VersionsPath>\<flavor>\<version>-<arch>, flavor is the folder include the version
+
+ Param:
+ 	- name: version name, e.g. 5.10.1 5.10.1-x86 1.0.0-x64
+
+ Return:
+ 	- path, e.g. x:\gnvm\versions\node\5.10.1-x64
+*/
+func VersionPath(name string) string {
+	return FlavorPath(VersionFlavor(name), name)
+}
+
+/*
+ Return folder of version in flavor, <VersionsPath>\<flavor>\<version>-<arch>, usage install
+
+ Param:
+ 	- flavor: FLAVOR_NODE or FLAVOR_IOJS
+ 	- name:   version name, e.g. 5.10.1 5.10.1-x86 1.0.0-x64
+
+ Return:
+ 	- path, e.g. x:\gnvm\versions\iojs\1.0.0-x64
+*/
+func FlavorPath(flavor, name string) string {
+	ver, arch, _, _ := ParseNodeVer(name)
+	return filepath.Join(VersionsPath, flavor, ver+"-"+DistArch(arch))
+}
+
+/*
+ Return flavor of installed version, io.js is installed to iojs folder from io.js index
+
+ Param:
+ 	- name: version name, e.g. 5.10.1 5.10.1-x86 1.0.0-x64
+
+ Return:
+ 	- FLAVOR_NODE or FLAVOR_IOJS, not installed is FLAVOR_NODE
+*/
+func VersionFlavor(name string) string {
+	if IsDirExist(FlavorPath(FLAVOR_IOJS, name), NODE) {
+		return FLAVOR_IOJS
+	}
+	return FLAVOR_NODE
+}
+
+/*
+ Return version name of version and arch, suffix only when arch is not current arch, e.g.
+ 	- 5.10.1 x64 -> 5.10.1 ( amd64 )
+ 	- 5.10.1 x86 -> 5.10.1-x86 ( amd64 )
+
+ Param:
+ 	- ver:  version, e.g. 5.10.1
+ 	- arch: x86 x64
+
+ Return:
+ 	- version name, usage globalversion latestversion and 'gnvm ls'
+*/
+func VersionName(ver, arch string) string {
+	if arch == "" || arch == DistArch(runtime.GOARCH) {
+		return ver
+	}
+	return ver + "-" + arch
+}
+
+/*
+ Return canonical version name, e.g. 5.10.1-x64 -> 5.10.1 ( amd64 ), invalid name return itself
+
+ Param:
+ 	- name: version name, e.g. 5.10.1 5.10.1-x64
+*/
+func CanonicalName(name string) string {
+	ver, arch, _, err := ParseNodeVer(name)
+	if err != nil || ver == LATEST {
+		return name
+	}
+	return VersionName(ver, DistArch(arch))
+}
+
+/*
+ Return all installed versions, walk <VersionsPath>\<flavor>\<version>-<arch>\node.exe
+
+ Return:
+ 	- version names, e.g. [5.10.1 5.10.1-x86 1.0.0]
+ 	- error
+*/
+func InstalledVersions() ([]string, error) {
+	var names []string
+	for _, flavor := range []string{FLAVOR_NODE, FLAVOR_IOJS} {
+		folders, err := ioutil.ReadDir(filepath.Join(VersionsPath, flavor))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return names, err
+		}
+		for _, folder := range folders {
+			arr := strings.SplitN(folder.Name(), "-", 2)
+			if !folder.IsDir() || len(arr) != 2 || !VerifyNodeVer(arr[0]) || (arr[1] != "x86" && arr[1] != "x64") {
+				continue
+			}
+			if IsDirExist(VersionsPath, flavor, folder.Name(), NODE) {
+				names = append(names, VersionName(arr[0], arr[1]))
+			}
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,240 @@
+package util
+
+import (
+	// lib
+	. "github.com/Kenshin/cprint"
+
+	// go
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	LOCK = ".gnvm.lock"
+
+	// default wait timeout of lock
+	LOCK_TIMEOUT = 2 * time.Minute
+)
+
+/*
+ Advisory lock file under gnvm root, all mutating commands take it, e.g. install use uninstall
+
+ - Path:    lock file path, e.g. <root>\.gnvm.lock
+ - Pid:     holder process id
+ - Command: holder command, e.g. gnvm install 5.10.1
+ - Time:    holder acquire time
+*/
+type Lock struct {
+	Path    string
+	Pid     int
+	Command string
+	Time    time.Time
+}
+
+// current process hold lock
+var held *Lock
+
+// flags of secret value, not write to lock file
+var secretFlags = []string{"--password", "--token"}
+
+/*
+ Acquire lock file under root, when locked by other alive process, wait until timeout
+
+ Param:
+ 	- root:    lock file folder, e.g. util.HomePath
+ 	- timeout: max wait time
+
+ Return:
+ 	- error: timeout or create lock file fail
+*/
+func AcquireLock(root string, timeout time.Duration) error {
+	if held != nil {
+		return nil
+	}
+
+	path := filepath.Join(root, LOCK)
+	lock := &Lock{path, os.Getpid(), lockCommand(os.Args[1:]), time.Now()}
+	deadline, waiting := time.Now().Add(timeout), false
+
+	for {
+		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if err == nil {
+			_, err = file.WriteString(lock.String())
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return err
+			}
+			held = lock
+			return nil
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil && os.IsNotExist(err) {
+			// released between create and read, retry
+			continue
+		} else if err != nil {
+			return err
+		}
+		owner, err := parseLock(path, data)
+		switch {
+		case err != nil && isFresh(path):
+			// holder is writing lock file, retry
+			time.Sleep(100 * time.Millisecond)
+			continue
+		case err != nil || !processAlive(owner.Pid):
+			// stale lock, e.g. holder crashed or os.Exit
+			if err := takeoverLock(path, data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return errors.New(fmt.Sprintf("wait lock file %v timeout %v, locked by process %v '%v' since %v.", path, timeout, owner.Pid, owner.Command, owner.Time.Format("2006-01-02 15:04:05")))
+		}
+		if !waiting {
+			P(WARING, "gnvm is locked by process %v '%v' since %v, waiting...\n", owner.Pid, owner.Command, owner.Time.Format("2006-01-02 15:04:05"))
+			waiting = true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+/*
+ Return command of lock file, value of secret flags and credential of url are redacted, e.g.
+ 	gnvm registry login corp --user alice --password secret -> gnvm registry login corp --user alice --password ***
+
+ Param:
+ 	- args: command arguments, without gnvm.exe
+*/
+func lockCommand(args []string) string {
+	arr := []string{"gnvm"}
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		for _, name := range secretFlags {
+			switch {
+			case arg == name && idx+1 < len(args):
+				arr, idx, arg = append(arr, arg), idx+1, "***"
+			case strings.HasPrefix(arg, name+"="):
+				arg = name + "=***"
+			}
+		}
+		arr = append(arr, arg)
+	}
+	return Redact(strings.Join(arr, " "))
+}
+
+/*
+ Remove stale lock file, other process maybe take over it at the same time, so rename it to unique name first,
+ then compare content with stale content, when not same, it is lock file of other process, restore it
+
+ Param:
+ 	- path: lock file path
+ 	- data: stale lock file content
+
+ Return:
+ 	- error: rename or remove fail
+*/
+func takeoverLock(path string, data []byte) error {
+	stale := fmt.Sprintf("%v.%v-%v", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, stale); err != nil {
+		if os.IsNotExist(err) {
+			// taken over by other process, retry
+			return nil
+		}
+		return err
+	}
+
+	if current, err := ioutil.ReadFile(stale); err == nil && string(current) != string(data) {
+		// lock file of other process is renamed, restore it when not any process create new one
+		if err := os.Link(stale, path); err != nil {
+			P(WARING, "restore lock file %v Error: %v\n", path, err.Error())
+		}
+		os.Remove(stale)
+		return nil
+	}
+
+	P(WARING, "remove stale lock file %v.\n", path)
+	if err := os.Remove(stale); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+/*
+ Release lock file when current process hold it
+*/
+func ReleaseLock() {
+	if held == nil {
+		return
+	}
+	if owner, err := readLock(held.Path); err == nil && owner.Pid == held.Pid {
+		os.Remove(held.Path)
+	}
+	held = nil
+}
+
+/*
+ Lock file content, format: <pid>\n<command>\n<time>
+*/
+func (this *Lock) String() string {
+	return strconv.Itoa(this.Pid) + "\n" + this.Command + "\n" + this.Time.Format(time.RFC3339) + "\n"
+}
+
+func readLock(path string) (*Lock, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseLock(path, data)
+}
+
+func parseLock(path string, data []byte) (*Lock, error) {
+	arr := strings.Split(strings.TrimSpace(strings.Replace(string(data), "\r", "", -1)), "\n")
+	if len(arr) != 3 {
+		return nil, errors.New("lock file " + path + " format error.")
+	}
+	pid, err := strconv.Atoi(arr[0])
+	if err != nil {
+		return nil, err
+	}
+	t, err := time.Parse(time.RFC3339, arr[2])
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{path, pid, arr[1], t}, nil
+}
+
+func isFresh(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) < 5*time.Second
+}
+
+/*
+ Judge process is alive, on Windows os.FindProcess fail when process not exist
+*/
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		process.Release()
+		return true
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
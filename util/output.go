@@ -0,0 +1,151 @@
+package util
+
+import (
+	// lib
+	"gopkg.in/yaml.v2"
+
+	// go
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const (
+	OUTPUT_JSON = "json"
+	OUTPUT_YAML = "yaml"
+	OUTPUT_TSV  = "tsv"
+)
+
+/*
+ Machine-readable output, set by global flag --output and --format
+
+ - Output: json yaml tsv, empty is human-readable( color ) output
+ - Format: go text/template, e.g. '{{range .Versions}}{{.Version}}{{"\n"}}{{end}}'
+*/
+var Output, Format string
+
+/*
+ Real stdout, when machine-readable output or 'gnvm env', os.Stdout redirect to os.Stderr
+*/
+var Stdout io.Writer = os.Stdout
+
+var redirected bool
+
+func init() {
+	// redirect before other package init print, e.g. config
+	// output of 'gnvm env' is run by shell line by line, only variable assignments to stdout
+	if _, ok := ArgValue("-o", "--output", "--format"); ok || ArgCommand() == "env" {
+		redirect()
+	}
+}
+
+func redirect() {
+	if !redirected {
+		Stdout, os.Stdout = os.Stdout, os.Stderr
+		redirected = true
+	}
+}
+
+/*
+ Tabular result, usage --output tsv
+*/
+type Table interface {
+	Header() []string
+	Rows() [][]string
+}
+
+/*
+ Verify --output and --format, when machine-readable output, redirect os.Stdout to os.Stderr
+
+ Return:
+	- error
+*/
+func InitOutput() error {
+	Output = strings.ToLower(Output)
+	switch Output {
+	case "", OUTPUT_JSON, OUTPUT_YAML, OUTPUT_TSV:
+	default:
+		return errors.New("--output only support " + OUTPUT_JSON + ", " + OUTPUT_YAML + " and " + OUTPUT_TSV + ".")
+	}
+	if Output != "" && Format != "" {
+		return errors.New("--output and --format can't be used together.")
+	}
+	if IsMachine() {
+		redirect()
+	}
+	return nil
+}
+
+/*
+ Is machine-readable output
+
+ Return:
+	- true( --output or --format ) false( human-readable )
+*/
+func IsMachine() bool {
+	return Output != "" || Format != ""
+}
+
+/*
+ Print result to real stdout by --output or --format, credential of output is redacted
+
+ Param:
+	- v: result struct, when --output tsv, v must be Table
+
+ Return:
+	- error
+*/
+func Render(v interface{}) error {
+	out := new(bytes.Buffer)
+	if err := render(out, v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(Stdout, Redact(out.String()))
+	return err
+}
+
+func render(w io.Writer, v interface{}) error {
+	if Format != "" {
+		tmpl, err := template.New("format").Funcs(template.FuncMap{"join": strings.Join}).Parse(Format)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(w, v); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+		return nil
+	}
+
+	switch Output {
+	case OUTPUT_JSON:
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(out))
+	case OUTPUT_YAML:
+		out, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, string(out))
+	case OUTPUT_TSV:
+		table, ok := v.(Table)
+		if !ok {
+			return errors.New("not support --output tsv.")
+		}
+		fmt.Fprintln(w, strings.Join(table.Header(), "\t"))
+		for _, row := range table.Rows() {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+	default:
+		return errors.New("not machine-readable output.")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	// go
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// default global packages file in home, one or more packages per line, # is comment
+const DEFAULT_PACKAGES = "default-packages"
+
+// npm package spec, e.g. typescript pnpm@8 @vue/cli@5.0.8
+var packageSpec = regexp.MustCompile(`^(@[a-zA-Z0-9~-][a-zA-Z0-9._~-]*/)?[a-zA-Z0-9~-][a-zA-Z0-9._~-]*(@[^\s@]+)?$`)
+
+/*
+ Split package list, separated by space or comma, # is comment
+
+ Param:
+ 	- value: e.g. "typescript, pnpm@8 @vue/cli"
+
+ Return:
+ 	- package specs, e.g. [typescript pnpm@8 @vue/cli]
+*/
+func SplitPackages(value string) []string {
+	var specs []string
+	for _, line := range strings.Split(value, "\n") {
+		specs = append(specs, strings.FieldsFunc(strings.SplitN(line, "#", 2)[0], func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r'
+		})...)
+	}
+	return specs
+}
+
+/*
+ Verify package list, usage config defaultpackages
+
+ Param:
+ 	- value: package list, empty is not any package
+
+ Return:
+ 	- error
+*/
+func VerifyPackages(value string) error {
+	for _, spec := range SplitPackages(value) {
+		if !packageSpec.MatchString(spec) {
+			return errors.New("include invalid npm package " + spec + ", e.g. typescript pnpm@8 @vue/cli")
+		}
+	}
+	return nil
+}
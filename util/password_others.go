@@ -0,0 +1,40 @@
+//go:build !windows
+// +build !windows
+
+package util
+
+import (
+	// go
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+/*
+ Read password from terminal without echo, when stdin is not terminal, read one line of stdin
+
+ Return:
+ 	- password
+ 	- error
+*/
+func ReadPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		stty := func(arg string) error {
+			cmd := exec.Command("stty", arg)
+			cmd.Stdin = os.Stdin
+			return cmd.Run()
+		}
+		if err := stty("-echo"); err == nil {
+			defer func() {
+				stty("echo")
+				os.Stdout.WriteString("\n")
+			}()
+		}
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
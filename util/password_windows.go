@@ -0,0 +1,45 @@
+//go:build windows
+// +build windows
+
+package util
+
+import (
+	// go
+	"bufio"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// console mode of echo input
+const ENABLE_ECHO_INPUT = 0x0004
+
+var (
+	kernel32           = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleMode = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode = kernel32.NewProc("SetConsoleMode")
+)
+
+/*
+ Read password from console without echo, when stdin is not console, read one line of stdin
+
+ Return:
+ 	- password
+ 	- error
+*/
+func ReadPassword() (string, error) {
+	handle, mode := syscall.Handle(os.Stdin.Fd()), uint32(0)
+	if ok, _, _ := procGetConsoleMode.Call(uintptr(handle), uintptr(unsafe.Pointer(&mode))); ok != 0 {
+		procSetConsoleMode.Call(uintptr(handle), uintptr(mode&^ENABLE_ECHO_INPUT))
+		defer func() {
+			procSetConsoleMode.Call(uintptr(handle), uintptr(mode))
+			os.Stdout.WriteString("\n")
+		}()
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,307 @@
+package util
+
+import (
+	// lib
+	"github.com/Kenshin/curl"
+	"gopkg.in/yaml.v2"
+
+	// go
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	POLICY     = "policy.yaml"
+	POLICY_ENV = "GNVM_POLICY"
+)
+
+/*
+ Organisation policy file, machine-wide %ProgramData%\gnvm\policy.yaml( /etc/gnvm/policy.yaml ),
+ when not exist, local file of env GNVM_POLICY, url not support, e.g.
+
+ 	allow:                      # allowed version ranges, empty is all
+ 	  - ">=18.0.0"
+ 	  - 16.20.*
+ 	block:                      # blocked versions or ranges, e.g. known-vulnerable
+ 	  - 18.0.0
+ 	  - ">=19.0.0 <20.0.0"
+ 	registries:                 # permitted registry url prefixes, empty is all
+ 	  - https://nodejs.org/dist/
+ 	  - https://nexus.corp/repository/node/
+ 	verify: true                # node.exe must be verified against SHASUMS256.txt
+
+ Range: space separated comparators are and, e.g. ">=16.0.0 <17.0.0"
+ 	- 18.19.0 18.*.* 18.x 18    exact or wildcard
+ 	- >=18 >18.0.0 <=20 <21 =18.19.0
+*/
+type Policy struct {
+	Allow      []string `yaml:"allow"`
+	Block      []string `yaml:"block"`
+	Registries []string `yaml:"registries"`
+	Verify     bool     `yaml:"verify"`
+	Path       string   `yaml:"-"`
+}
+
+var (
+	policy       *Policy
+	policyErr    error
+	policyLoaded bool
+)
+
+/*
+ Return policy file path, machine-wide file first, empty is not found
+*/
+func policyPath() (string, error) {
+	system := filepath.Join("/etc", "gnvm", POLICY)
+	if runtime.GOOS == "windows" {
+		system = filepath.Join(os.Getenv("ProgramData"), "gnvm", POLICY)
+	}
+	if IsDirExist(system) {
+		return system, nil
+	}
+	path := os.Getenv(POLICY_ENV)
+	if path == "" {
+		return "", nil
+	}
+	if ok, _ := regexp.MatchString(`^[a-zA-Z][a-zA-Z0-9+.-]+://`, path); ok {
+		return path, errors.New(POLICY_ENV + " must be local file, not support url " + path + ".")
+	}
+	if !IsDirExist(path) {
+		return path, errors.New("policy file " + path + " not exist.")
+	}
+	return path, nil
+}
+
+/*
+ Return current policy, load once
+
+ Return:
+ 	- *Policy: nil is not any policy
+ 	- error:   policy file invalid, all policy check refuse
+*/
+func GetPolicy() (*Policy, error) {
+	if policyLoaded {
+		return policy, policyErr
+	}
+	policyLoaded = true
+
+	path, err := policyPath()
+	if path == "" || err != nil {
+		policyErr = err
+		return policy, policyErr
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		policyErr = errors.New("read policy file " + path + " Error: " + err.Error())
+		return policy, policyErr
+	}
+	p := &Policy{Path: path}
+	if err := yaml.UnmarshalStrict(data, p); err != nil {
+		policyErr = errors.New("parse policy file " + path + " Error: " + err.Error())
+		return policy, policyErr
+	}
+	for _, r := range append(append([]string{}, p.Allow...), p.Block...) {
+		if _, err := MatchRange("0.0.0", r); err != nil {
+			policyErr = errors.New("policy file " + path + " range " + r + " " + err.Error())
+			return policy, policyErr
+		}
+	}
+	policy = p
+	return policy, nil
+}
+
+/*
+ Verify Node.js version is allowed by policy
+
+ Param:
+ 	- version: Node.js version, e.g. 18.19.0 18.19.0-x86
+
+ Return:
+ 	- error: refusal reason, nil is allowed
+*/
+func PolicyVersion(version string) error {
+	p, err := GetPolicy()
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	ver := strings.Split(strings.TrimPrefix(version, "v"), "-")[0]
+	for _, r := range p.Block {
+		if ok, _ := MatchRange(ver, r); ok {
+			return errors.New("Node.js version " + ver + " is blocked by policy " + p.Path + ", rule: " + r + ".")
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, r := range p.Allow {
+		if ok, _ := MatchRange(ver, r); ok {
+			return nil
+		}
+	}
+	return errors.New("Node.js version " + ver + " is not allowed by policy " + p.Path + ", allowed: " + strings.Join(p.Allow, ", ") + ".")
+}
+
+/*
+ Verify registry url is permitted by policy, compare without credential and case of host
+
+ Param:
+ 	- url: registry url, e.g. https://nexus.corp/repository/node/
+
+ Return:
+ 	- error: refusal reason, nil is permitted
+*/
+func PolicyRegistry(url string) error {
+	p, err := GetPolicy()
+	if err != nil {
+		return err
+	}
+	if p == nil || len(p.Registries) == 0 {
+		return nil
+	}
+	normalize := func(value string) string {
+		value, _ = SplitCredential(value)
+		if !strings.HasSuffix(value, "/") {
+			value += "/"
+		}
+		return strings.ToLower(value)
+	}
+	for _, v := range p.Registries {
+		if strings.HasPrefix(normalize(url), normalize(v)) {
+			return nil
+		}
+	}
+	return errors.New("registry " + Redact(url) + " is not permitted by policy " + p.Path + ", permitted: " + strings.Join(p.Registries, ", ") + ".")
+}
+
+/*
+ Return policy require node.exe verified against SHASUMS256.txt
+*/
+func PolicyVerify() bool {
+	p, err := GetPolicy()
+	return err == nil && p != nil && p.Verify
+}
+
+/*
+ Match version by range
+
+ Param:
+ 	- version: Node.js version, e.g. 18.19.0
+ 	- r:       range, e.g. 18.19.0 18.*.* 18.x >=18.0.0 ">=16.0.0 <17.0.0"
+
+ Return:
+ 	- true( match ) false( not match )
+ 	- error: range format error
+*/
+func MatchRange(version, r string) (bool, error) {
+	fields := strings.Fields(r)
+	if len(fields) == 0 {
+		return false, errors.New("range can't be empty.")
+	}
+	match := true
+	for _, field := range fields {
+		op := ""
+		for _, v := range []string{">=", "<=", ">", "<", "="} {
+			if strings.HasPrefix(field, v) {
+				op = v
+				break
+			}
+		}
+		bound := strings.TrimPrefix(field[len(op):], "v")
+		if ok, _ := regexp.MatchString(`^(\d+|[xX*])(\.(\d+|[xX*])){0,2}$`, bound); !ok {
+			return false, errors.New("format error, e.g. 18.19.0 18.*.* >=18.0.0")
+		}
+		switch op {
+		case "", "=":
+			arr, ver := strings.Split(bound, "."), strings.Split(version, ".")
+			for i, v := range arr {
+				if v != "x" && v != "X" && v != "*" && (i >= len(ver) || v != ver[i]) {
+					match = false
+				}
+			}
+		case ">=", ">", "<=", "<":
+			if strings.ContainsAny(bound, "xX*") {
+				return false, errors.New("format error, comparator not support wildcard " + field)
+			}
+			// partial bound is whole major or minor, only compare parts of bound, e.g. <=20 match 20.5.1, >20.1 not match 20.1.9
+			ver := strings.Split(strings.Split(strings.TrimPrefix(version, "v"), "-")[0], ".")
+			if parts := len(strings.Split(bound, ".")); parts < len(ver) {
+				ver = ver[:parts]
+			}
+			c := CompareNodeVer(strings.Join(ver, "."), bound)
+			switch op {
+			case ">=":
+				match = match && c >= 0
+			case ">":
+				match = match && c > 0
+			case "<=":
+				match = match && c <= 0
+			case "<":
+				match = match && c < 0
+			}
+		}
+	}
+	return match, nil
+}
+
+/*
+ Verify file against SHASUMS256.txt of release
+
+ Param:
+ 	- sums: SHASUMS256.txt url, e.g. http://nodejs.org/dist/v18.19.0/SHASUMS256.txt
+ 	- name: file name in SHASUMS256.txt, e.g. win-x64/node.exe
+ 	- path: local file path
+
+ Return:
+ 	- error: nil is verified
+*/
+func VerifyChecksum(sums, name, path string) error {
+	code, res, err := curl.Get(sums)
+	if code != 0 {
+		if res != nil && res.Body != nil {
+			res.Body.Close()
+		}
+		if err == nil {
+			err = errors.New("status code " + strconv.Itoa(code))
+		}
+		return errors.New("get " + Redact(sums) + " Error: " + Redact(err.Error()))
+	}
+	defer res.Body.Close()
+
+	expect := ""
+	curl.ReadLine(res.Body, func(content string, line int) bool {
+		if arr := strings.Fields(content); len(arr) == 2 && arr[1] == name {
+			expect = strings.ToLower(arr[0])
+			return true
+		}
+		return false
+	})
+	if expect == "" {
+		return errors.New("not found " + name + " in " + Redact(sums) + ".")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expect {
+		return errors.New(name + " checksum mismatch, expect " + expect + ", actual " + actual + ".")
+	}
+	return nil
+}
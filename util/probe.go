@@ -0,0 +1,98 @@
+package util
+
+import (
+	// go
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// default timeout of probe
+const PROBE_TIMEOUT = 10 * time.Second
+
+/*
+ Probe result of url
+
+ - URL:        probe url
+ - Code:       response status code, 0 is request fail
+ - Latency:    time to response header
+ - Duration:   time to read whole body
+ - Size:       body bytes
+ - Throughput: body bytes per second
+ - Err:        nil is success( 200 )
+*/
+type ProbeResult struct {
+	URL        string
+	Code       int
+	Latency    time.Duration
+	Duration   time.Duration
+	Size       int64
+	Throughput float64
+	Err        error
+}
+
+/*
+ Probe url with context, GET and read whole body, measure latency and throughput
+
+ Param:
+ 	- ctx:    context, usage cancel and timeout
+ 	- method: http method, include: GET HEAD
+ 	- url:    probe url, e.g. http://nodejs.org/dist/latest/SHASUMS256.txt
+
+ Return:
+ 	- ProbeResult
+*/
+func Probe(ctx context.Context, method, url string) ProbeResult {
+	result := ProbeResult{URL: url}
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	start := time.Now()
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer res.Body.Close()
+	result.Code, result.Latency = res.StatusCode, time.Since(start)
+
+	if result.Size, err = io.Copy(ioutil.Discard, res.Body); err != nil {
+		result.Err = err
+		return result
+	}
+	result.Duration = time.Since(start)
+	if method == http.MethodHead {
+		result.Size = res.ContentLength
+	} else if result.Duration > 0 {
+		result.Throughput = float64(result.Size) / result.Duration.Seconds()
+	}
+
+	if res.StatusCode != http.StatusOK {
+		result.Err = errors.New("response code " + strconv.Itoa(res.StatusCode))
+	}
+	return result
+}
+
+/*
+ Probe url with timeout, usage single request
+
+ Param:
+ 	- method:  http method, include: GET HEAD
+ 	- url:     probe url
+ 	- timeout: request timeout
+
+ Return:
+ 	- ProbeResult
+*/
+func ProbeTimeout(method, url string, timeout time.Duration) ProbeResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return Probe(ctx, method, url)
+}
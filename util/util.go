@@ -10,6 +10,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,6 +18,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 )
 
 const (
@@ -33,12 +35,19 @@ const (
 	ORIGIN_TAOBAO  = "http://npm.taobao.org/mirrors/node/"
 	NODELIST       = "index.json"
 	SHASUMS        = "SHASUMS256.txt"
+
+	IOJS_DEFAULT = "http://iojs.org/dist/"
+	IOJS_TAOBAO  = "http://npm.taobao.org/mirrors/iojs/"
+	NPM_DEFAULT  = "https://github.com/npm/npm/releases/"
+	NPM_TAOBAO   = "http://npm.taobao.org/mirrors/npm/"
+	NPM_METADATA = "https://raw.githubusercontent.com/npm/npm/master/package.json"
+	UPDATE_URL   = "http://ksria.com/gnvm/"
 )
 
 var DIVIDE = string(os.PathSeparator)
 
 /*
-  Golbal node.exe path
+  Golbal node.exe path, default of config noderoot, see initHome()
 */
 var GlobalNodePath string
 
@@ -52,7 +61,7 @@ func init() {
 		}
 	}()
 
-	GlobalNodePath = getGlobalNodePath()
+	initHome()
 }
 
 /*
@@ -124,6 +133,38 @@ func FormatNodeVer(version string) float64 {
 	return float64
 }
 
+/*
+ Compare Node.js version, not limit digit of each part
+
+ Param:
+	- v1: Node.js version, e.g. "5.10.0" or "5.10.0-x86"
+	- v2: Node.js version
+
+ Return:
+	- -1: v1 < v2
+	-  0: v1 == v2
+	-  1: v1 > v2
+*/
+func CompareNodeVer(v1, v2 string) int {
+	arr1 := strings.Split(strings.Split(strings.TrimPrefix(v1, "v"), "-")[0], ".")
+	arr2 := strings.Split(strings.Split(strings.TrimPrefix(v2, "v"), "-")[0], ".")
+	for i := 0; i < 3; i++ {
+		n1, n2 := 0, 0
+		if i < len(arr1) {
+			n1, _ = strconv.Atoi(arr1[i])
+		}
+		if i < len(arr2) {
+			n2, _ = strconv.Atoi(arr2[i])
+		}
+		if n1 < n2 {
+			return -1
+		} else if n1 > n2 {
+			return 1
+		}
+	}
+	return 0
+}
+
 /*
  Format wildcard node version
 
@@ -188,36 +229,7 @@ func FormatLatVer(latest *string, value string, print bool) {
 }
 
 /*
- Get Node.js version level( 0 ~ 4 )
-
- Param:
-	- ver: Node.js float64 version, usage FormatNodeVer() return.
-
- Return:
-	- 0: no exec
-	- 1: only x86 exec
-	- 2: x86 and x64 exec, folder is "x64/" and <root>
-	- 3: io.js exec, folder is "win-x64/" and "win-x86/"
-	- 4: x86 and x64 exec, folder is "win-x64/" and "win-x86/"
-*/
-func GetNodeVerLev(ver float64) (level int) {
-	switch {
-	case ver <= 0.0500:
-		level = 0
-	case ver > 0.0500 && ver <= 0.0612:
-		level = 1
-	case ver > 0.0612 && ver < 1:
-		level = 2
-	case ver >= 1 && ver <= 3.0301:
-		level = 3
-	case ver > 3.0301:
-		level = 4
-	}
-	return
-}
-
-/*
- Parse arguments return version, io, suffix and arch
+ Parse arguments return version, suffix and arch
 
  Param:
  	s support format: <version>-<arch>, e.g.
@@ -226,13 +238,12 @@ func GetNodeVerLev(ver float64) (level int) {
 
  Return:
 	- ver    : x.xx.xx
-	- iojs   : true  and false
 	- arch   : "386" and "amd64"
 	- suffix : "x86" and "x64"  and ""
-	- err    : includ, "1" "2", "3", "4", "5"
+	- err    : includ, "2", "3", "4", "5"
 
 */
-func ParseNodeVer(s string) (ver string, iojs bool, arch, suffix string, err error) {
+func ParseNodeVer(s string) (ver string, arch, suffix string, err error) {
 	arr := strings.Split(strings.ToLower(s), "-")
 
 	// get ver
@@ -249,7 +260,6 @@ func ParseNodeVer(s string) (ver string, iojs bool, arch, suffix string, err err
 		if len(arr) > 1 {
 			P(WARING, "%v parameter not support suffix.\n", s)
 		}
-		iojs = false
 		arch = runtime.GOARCH
 		suffix = ""
 		return
@@ -261,16 +271,6 @@ func ParseNodeVer(s string) (ver string, iojs bool, arch, suffix string, err err
 		return
 	}
 
-	switch GetNodeVerLev(FormatNodeVer(ver)) {
-	case 0:
-		// no exec
-		err = errors.New("1")
-		return
-	case 3:
-		// get iojs
-		iojs = true
-	}
-
 	// get arch
 	if len(arr) == 2 {
 		if ok, _ := regexp.MatchString(`^x?(86|64)$`, arr[1]); ok {
@@ -351,40 +351,26 @@ func GetLatVer(url string) string {
 
  Param:
 	- url:     remote Node.js url, e.g. http://npm.taobao.org/mirrors/node
+	- tmpl:    download url template of registry, empty is standard layout, e.g. {base}/{version}/{flavor}-{version}-{os}-{arch}.{ext}
+	- flavor:  FLAVOR_NODE or FLAVOR_IOJS, which index listed the version
 	- version: Node.js version
 	- arch:    remote node.exe arch, include: "amd64" and "386"
+	- files:   index.json files of the version, e.g. [win-x64-exe win-x86-exe]
+	           nil is index not provide files, e.g. html directory listing, not verify.
 
  Return:
 	- url:     remote node.exe url, e.g. http://npm.taobao.org/mirrors/node/v5.9.0/win-x64/node.exe
+	- error:   when files not include node.exe of arch
 */
-func GetRemoteNodePath(url, version, arch string) (string, error) {
+func GetRemoteNodePath(url, tmpl, flavor, version, arch string, files []string) (string, error) {
 	version = strings.Split(version, "-")[0]
-	folder, exec, level := "/", NODE, GetNodeVerLev(FormatNodeVer(version))
-
-	switch level {
-	case 0:
-		P(ERROR, "downlaod Node.js version %v, not %v. See '%v'.\n", version, "node.exe", "gnvm help install")
-		return "", errors.New("Not support version " + version + "download.")
-	case 1:
-		P(WARING, "downlaod Node.js version %v, not %v node.exe.\n", version, "x64")
-	case 2:
-		if arch == "amd64" {
-			folder = "/x64/"
-		}
-	default:
-		if arch == "amd64" {
-			folder = "/win-x64/"
-		} else {
-			folder = "/win-x86/"
+	entry := ExeEntry(arch)
+	if files != nil {
+		if err := VerifyDistFile(version, files, entry); err != nil {
+			return "", err
 		}
 	}
-
-	// when level == 3, exec is "iojs.exe"
-	if level == 3 {
-		exec = IOJS
-	}
-
-	return url + "v" + version + folder + exec, nil
+	return ParseDistFile(entry).URL(tmpl, url, flavor, version), nil
 }
 
 /*
@@ -524,6 +510,90 @@ func Copy(src, dst, name string) (err error) {
 	return
 }
 
+/*
+ Move folder from src to dst, when src and dst are different volumes, copy and remove src
+
+ Param:
+ 	- src: source folder
+ 	- dst: target folder, must not exist
+
+ Return:
+ 	- error
+*/
+func Move(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+	if err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), info.Mode().Perm()|0700)
+		}
+		return Copy(filepath.Dir(path), filepath.Dir(filepath.Join(dst, rel)), info.Name())
+	}); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+/*
+ Judge rename error is cross volume, e.g. Windows ERROR_NOT_SAME_DEVICE and Unix EXDEV
+*/
+func isCrossDevice(err error) bool {
+	if le, ok := err.(*os.LinkError); ok {
+		if errno, ok := le.Err.(syscall.Errno); ok {
+			return errno == syscall.EXDEV || runtime.GOOS == "windows" && errno == 17
+		}
+	}
+	return false
+}
+
+/*
+ Crash-safe write file, write to a temp file in the same folder, fsync and rename over the original
+
+ Param:
+ 	- path: target file path
+ 	- data: file content
+ 	- perm: file mode
+
+ Return:
+ 	- error
+*/
+func WriteFile(path string, data []byte, perm os.FileMode) (err error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		return
+	}
+	if err = tmp.Sync(); err != nil {
+		return
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return
+	}
+	err = os.Rename(tmp.Name(), path)
+	return
+}
+
 /*
  Judge path( folder ) or file exist
 
@@ -543,11 +613,72 @@ func IsDirExist(paths ...string) bool {
 	return true
 }
 
+/*
+ Get flag value from os.Args before cobra parse, usage package init
+
+ Param:
+ 	- names: flag names, e.g. "-o", "--output"
+
+ Return:
+ 	- value: flag value, support '--name value' '--name=value' '-nvalue'
+ 	- bool:  true( exist ) false( not exist )
+*/
+func ArgValue(names ...string) (string, bool) {
+	for idx, arg := range os.Args[1:] {
+		if arg == "--" {
+			break
+		}
+		for _, name := range names {
+			switch {
+			case arg == name:
+				if idx+2 < len(os.Args) {
+					return os.Args[idx+2], true
+				}
+				return "", true
+			case strings.HasPrefix(arg, name+"="):
+				return arg[len(name)+1:], true
+			case len(name) == 2 && strings.HasPrefix(arg, name) && !strings.HasPrefix(arg, "--"):
+				return arg[2:], true
+			}
+		}
+	}
+	return "", false
+}
+
+/*
+ Get sub command name from os.Args before cobra parse, usage package init
+
+ Return:
+ 	- name: first argument not flag and not value of global flag, e.g. env, empty is not any
+*/
+func ArgCommand() string {
+	values := map[string]bool{"-o": true, "--output": true, "--format": true, "-c": true, "--set": true, "--home": true, "--lock-timeout": true}
+	for idx := 1; idx < len(os.Args); idx++ {
+		switch arg := os.Args[idx]; {
+		case arg == "--":
+			return ""
+		case values[arg]:
+			idx++
+		case !strings.HasPrefix(arg, "-"):
+			return arg
+		}
+	}
+	return ""
+}
+
+/*
+ Return legacy global node.exe folder, discovery by session, node.exe or gnvm.exe in PATH, usage 'gnvm migrate' when GNVM_HOME is set
+*/
+func LegacyNodePath() string {
+	return getGlobalNodePath()
+}
+
 func getGlobalNodePath() string {
 	var path string
 
 	if env, ok := IsSessionEnv("", false); ok {
-		if reg, err := regexp.Compile(`\\([0]|[1-9]\d?)(\.([0]|[1-9]\d?)){2}(-x(86|64))?\\$`); err == nil {
+		// e.g. <root>\versions\node\5.10.1-x64\ or legacy <root>\5.10.1\
+		if reg, err := regexp.Compile(`(\\versions\\(node|iojs))?\\([0]|[1-9]\d?)(\.([0]|[1-9]\d?)){2}(-x(86|64))?\\$`); err == nil {
 			ver := reg.FindString(env)
 			path = strings.Replace(env, ver, "", -1)
 		}